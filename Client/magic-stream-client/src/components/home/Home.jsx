@@ -1,24 +1,36 @@
 import { useState, useEffect } from "react";
+import Button from 'react-bootstrap/Button'
 import axiosClient from '..//..//api/axiosConfig'
 import Movies from '../movies/Movies'
 
 const Home = ({updateMovieReview}) => {
     const [movies, setMovies] = useState([]);
     const [loading, setLoading] = useState(false)
+    const [loadingMore, setLoadingMore] = useState(false)
     const [message, setMessage] = useState()
+    //Cursor of the following page, empty when the last page was loaded
+    const [nextCursor, setNextCursor] = useState("")
 
-    //When Home component loads, we use UseEffect to call the movies endpoint on the server and populate movies collection with 
+    //Fetch one page of movies. Without cursor the first page replaces the movies, otherwise the page is appended
+    const fetchMovies = async (cursor) => {
+        //Movies endpoint is paginated, movies of the page are returned on items
+        const response = await axiosClient.get('/movies', {params: cursor ? {cursor} : {}});
+        setMovies((previous) => cursor ? [...previous, ...response.data.items] : response.data.items);
+        setNextCursor(response.data.next_cursor || "");
+        return response.data.items;
+    }
+
+    //When Home component loads, we use UseEffect to call the movies endpoint on the server and populate movies collection with
     //movie data from the server
     useEffect(() => {
-        const fetchMovies = async () => {
+        const fetchFirstPage = async () => {
             //Display loading indicator while servserside code is retrieving relevant data
             setLoading(true);
             setMessage("");
             try{
                 //Call serverside endpoint
-                const response = await axiosClient.get('/movies');
-                setMovies(response.data);
-                if(response.data.length == 0){
+                const items = await fetchMovies();
+                if(items.length == 0){
                     setMessage("There are currently no movies available")
                 }
             }catch(error){
@@ -28,20 +40,41 @@ const Home = ({updateMovieReview}) => {
                 setLoading(false)
             }
         }
-        fetchMovies();
+        fetchFirstPage();
     }, [])
 
+    //Append the following page of movies to the ones already shown
+    const loadMore = async () => {
+        setLoadingMore(true);
+        try{
+            await fetchMovies(nextCursor);
+        }catch(error){
+            console.error('Error fetching more movies')
+        }finally{
+            setLoadingMore(false)
+        }
+    }
+
     return (
         <>
             {loading ? (
                 <h2>Loading...</h2>
             ) : (
-                <Movies movies= {movies} updateMovieReview = {updateMovieReview}  message={message}/>
+                <>
+                    <Movies movies= {movies} updateMovieReview = {updateMovieReview}  message={message}/>
+                    {nextCursor && (
+                        <div className="container text-center my-4">
+                            <Button variant="info" onClick={loadMore} disabled={loadingMore}>
+                                {loadingMore ? "Loading..." : "Load more"}
+                            </Button>
+                        </div>
+                    )}
+                </>
             )}
         </>
     );
 
-    
+
 };
 
-export default Home
\ No newline at end of file
+export default Home
@@ -0,0 +1,271 @@
+package catalog
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Export only format, schema.org Movie objects inside a JSON-LD graph
+const FormatJSONLD = "jsonld"
+
+// Amount of movies written between flushes of the response
+const exportFlushEvery = 100
+
+// Content type and file extension of every export format
+var exportFormats = map[string]struct {
+	ContentType string
+	Extension   string
+}{
+	FormatNDJSON: {"application/x-ndjson", "ndjson"},
+	FormatCSV:    {"text/csv; charset=utf-8", "csv"},
+	FormatJSONLD: {"application/ld+json", "jsonld"},
+}
+
+// Function that returns content type and file extension of an export format, ok is false for unknown formats
+func ExportFormat(format string) (contentType string, extension string, ok bool) {
+	f, ok := exportFormats[format]
+	return f.ContentType, f.Extension, ok
+}
+
+// Function that opens a cursor over every movie that is not archived in insertion order
+func OpenExportCursor(ctx context.Context, client *mongo.Client) (*mongo.Cursor, error) {
+	var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	return movieCollection.Find(ctx, bson.M{"archived": bson.M{"$ne": true}}, findOptions)
+}
+
+// Function that streams the movies of cursor to w one document at a time, never holding the whole catalog in memory
+func WriteExport(ctx context.Context, cursor *mongo.Cursor, w io.Writer, format string) error {
+	buffered := bufio.NewWriter(w)
+	flusher, _ := w.(http.Flusher)
+
+	flush := func() error {
+		if err := buffered.Flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	var writeMovie func(movie models.Movie, index int) error
+	var finish func() error
+
+	switch format {
+	case FormatNDJSON:
+		encoder := json.NewEncoder(buffered)
+		writeMovie = func(movie models.Movie, index int) error {
+			return encoder.Encode(movie)
+		}
+
+	case FormatCSV:
+		csvWriter := csv.NewWriter(buffered)
+		if err := csvWriter.Write(append(append([]string{}, CSVColumns...), CSVOptionalColumns...)); err != nil {
+			return err
+		}
+		writeMovie = func(movie models.Movie, index int) error {
+			return csvWriter.Write(movieCSVRecord(movie))
+		}
+		finish = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+
+	case FormatJSONLD:
+		if _, err := io.WriteString(buffered, `{"@context":"https://schema.org","@graph":[`); err != nil {
+			return err
+		}
+		writeMovie = func(movie models.Movie, index int) error {
+			if index > 0 {
+				if err := buffered.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			data, err := json.Marshal(schemaOrgMovie(movie))
+			if err != nil {
+				return err
+			}
+			_, err = buffered.Write(data)
+			return err
+		}
+		finish = func() error {
+			_, err := io.WriteString(buffered, "]}\n")
+			return err
+		}
+
+	default:
+		return ErrUnsupportedFormat
+	}
+
+	index := 0
+	for cursor.Next(ctx) {
+		var movie models.Movie
+
+		if err := cursor.Decode(&movie); err != nil {
+			return err
+		}
+
+		if err := writeMovie(movie, index); err != nil {
+			return err
+		}
+		index++
+
+		if index%exportFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if finish != nil {
+		if err := finish(); err != nil {
+			return err
+		}
+	}
+
+	return flush()
+}
+
+// Function that returns a movie as a CSV record with the same columns accepted by the import
+func movieCSVRecord(movie models.Movie) []string {
+	genres := make([]string, 0, len(movie.Genre))
+	for _, genre := range movie.Genre {
+		genres = append(genres, strconv.Itoa(genre.GenreID)+":"+genre.GenreName)
+	}
+
+	releaseDate := ""
+	if movie.ReleaseDate != nil {
+		releaseDate = movie.ReleaseDate.UTC().Format(csvDateLayout)
+	}
+
+	runtime := ""
+	if movie.RuntimeMinutes != 0 {
+		runtime = strconv.Itoa(movie.RuntimeMinutes)
+	}
+
+	return []string{
+		movie.ImdbID,
+		movie.Title,
+		movie.PosterPath,
+		movie.YoutubeID,
+		strings.Join(genres, "|"),
+		movie.AdminReview,
+		strconv.Itoa(movie.Ranking.RankingValue),
+		movie.Ranking.RankingName,
+		releaseDate,
+		runtime,
+		movie.Synopsis,
+		movie.OriginalLanguage,
+		strings.Join(movie.SpokenLanguages, "|"),
+		movie.ContentRating,
+		strings.Join(movie.Directors, "|"),
+		strings.Join(movie.Cast, "|"),
+		movie.Type,
+	}
+}
+
+// schema.org Movie (https://schema.org/Movie), or TVSeries (https://schema.org/TVSeries) for series
+type schemaMovie struct {
+	Type       string       `json:"@type"`
+	ID         string       `json:"@id"`
+	Identifier string       `json:"identifier"`
+	Name       string       `json:"name"`
+	Image      string       `json:"image,omitempty"`
+	Genre      []string     `json:"genre,omitempty"`
+	Trailer    *schemaVideo `json:"trailer,omitempty"`
+
+	DatePublished string         `json:"datePublished,omitempty"`
+	Duration      string         `json:"duration,omitempty"` //ISO 8601 duration, for example PT142M
+	Description   string         `json:"description,omitempty"`
+	InLanguage    string         `json:"inLanguage,omitempty"`
+	ContentRating string         `json:"contentRating,omitempty"`
+	Director      []schemaPerson `json:"director,omitempty"`
+	Actor         []schemaPerson `json:"actor,omitempty"`
+}
+
+// schema.org Person (https://schema.org/Person)
+type schemaPerson struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// schema.org VideoObject (https://schema.org/VideoObject)
+type schemaVideo struct {
+	Type         string `json:"@type"`
+	Name         string `json:"name"`
+	EmbedURL     string `json:"embedUrl"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+}
+
+// Function that converts a movie into a schema.org Movie object, series become TVSeries objects
+func schemaOrgMovie(movie models.Movie) schemaMovie {
+	series := movie.Type == models.ContentTypeSeries
+
+	schemaType := "Movie"
+	if series {
+		schemaType = "TVSeries"
+	}
+
+	result := schemaMovie{
+		Type:       schemaType,
+		ID:         "https://www.imdb.com/title/" + movie.ImdbID + "/",
+		Identifier: movie.ImdbID,
+		Name:       movie.Title,
+		Image:      movie.PosterPath,
+
+		Description:   movie.Synopsis,
+		InLanguage:    movie.OriginalLanguage,
+		ContentRating: movie.ContentRating,
+	}
+
+	if movie.ReleaseDate != nil {
+		result.DatePublished = movie.ReleaseDate.UTC().Format(csvDateLayout)
+	}
+
+	//Runtime of a series is the one of its episodes, TVSeries has no duration
+	if movie.RuntimeMinutes != 0 && !series {
+		result.Duration = "PT" + strconv.Itoa(movie.RuntimeMinutes) + "M"
+	}
+
+	for _, name := range movie.Directors {
+		result.Director = append(result.Director, schemaPerson{Type: "Person", Name: name})
+	}
+
+	for _, name := range movie.Cast {
+		result.Actor = append(result.Actor, schemaPerson{Type: "Person", Name: name})
+	}
+
+	for _, genre := range movie.Genre {
+		result.Genre = append(result.Genre, genre.GenreName)
+	}
+
+	if movie.YoutubeID != "" {
+		result.Trailer = &schemaVideo{
+			Type:         "VideoObject",
+			Name:         movie.Title + " trailer",
+			EmbedURL:     "https://www.youtube.com/embed/" + movie.YoutubeID,
+			ThumbnailURL: "https://img.youtube.com/vi/" + movie.YoutubeID + "/hqdefault.jpg",
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,525 @@
+// Package catalog holds the bulk operations over the movies catalog shared by the admin endpoints and the command line
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Supported import formats
+const FormatCSV = "csv"
+const FormatNDJSON = "ndjson"
+
+// Columns every CSV import file must have on its header row. Genres are written as id:name pairs separated by |
+var CSVColumns = []string{"imdb_id", "title", "poster_path", "youtube_id", "genre", "admin_review", "ranking_value", "ranking_name"}
+
+// Metadata columns a CSV import file may have. Dates are written as YYYY-MM-DD, lists are separated by | and type is
+// series for TV series, empty or movie for films
+var CSVOptionalColumns = []string{"release_date", "runtime_minutes", "synopsis", "original_language", "spoken_languages", "content_rating", "directors", "cast", "type"}
+
+// Optional fields of an import row: the CSV optional columns and the credits NDJSON lines may carry
+var importOptionalFields = append(append([]string{}, CSVOptionalColumns...), "credits")
+
+// Layout of release dates on CSV files
+const csvDateLayout = "2006-01-02"
+
+var ErrUnsupportedFormat = errors.New("unsupported import format, use csv or ndjson")
+
+var validate = validator.New()
+
+// Row read from an import file. Err is set when the row could not be parsed
+type importRow struct {
+	number  int
+	movie   models.Movie
+	present map[string]bool //Optional columns the row carries (header columns of a CSV file, keys of a JSON line)
+	err     error
+}
+
+// Function that reads movies in CSV or NDJSON format from reader, validates every row and upserts them by imdb_id.
+// When dryRun is true nothing is written but the report classifies rows as if it was
+func ImportMovies(ctx context.Context, client *mongo.Client, reader io.Reader, format string, dryRun bool) (models.ImportReport, error) {
+	report := models.ImportReport{
+		DryRun:   dryRun,
+		Inserted: []models.ImportRowResult{},
+		Updated:  []models.ImportRowResult{},
+		Skipped:  []models.ImportRowResult{},
+		Failed:   []models.ImportRowResult{},
+	}
+
+	var next func() (*importRow, error)
+	var err error
+
+	switch format {
+	case FormatCSV:
+		next, err = csvRows(reader)
+	case FormatNDJSON:
+		next = ndjsonRows(reader)
+	default:
+		err = ErrUnsupportedFormat
+	}
+
+	if err != nil {
+		return report, err
+	}
+
+	var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+	//imdb ids already seen on this file, a second row with the same id is skipped
+	seen := map[string]int{}
+
+	for {
+		row, err := next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return report, err
+		}
+
+		report.Total++
+		result := models.ImportRowResult{Row: row.number, ImdbID: row.movie.ImdbID}
+
+		if row.err != nil {
+			result.Reason = row.err.Error()
+			report.Failed = append(report.Failed, result)
+			continue
+		}
+
+		//Same validation rules used by AddMovie
+		if err := validate.Struct(row.movie); err != nil {
+			result.Reason = err.Error()
+			report.Failed = append(report.Failed, result)
+			continue
+		}
+
+		if first, ok := seen[row.movie.ImdbID]; ok {
+			result.Reason = fmt.Sprintf("duplicate of row %d", first)
+			report.Skipped = append(report.Skipped, result)
+			continue
+		}
+		seen[row.movie.ImdbID] = row.number
+
+		//Credited people must exist, their names replace the directors and cast of the row
+		if err := utils.ApplyCredits(ctx, client, &row.movie); err != nil {
+			result.Reason = err.Error()
+			report.Failed = append(report.Failed, result)
+			continue
+		}
+
+		outcome, err := upsertMovie(ctx, movieCollection, row.movie, row.present, dryRun)
+
+		if err != nil {
+			result.Reason = err.Error()
+			report.Failed = append(report.Failed, result)
+			continue
+		}
+
+		switch outcome {
+		case upsertInserted:
+			report.Inserted = append(report.Inserted, result)
+		case upsertUpdated:
+			report.Updated = append(report.Updated, result)
+		default:
+			result.Reason = "no changes"
+			report.Skipped = append(report.Skipped, result)
+		}
+	}
+
+	return report, nil
+}
+
+type upsertOutcome int
+
+const (
+	upsertUnchanged upsertOutcome = iota
+	upsertInserted
+	upsertUpdated
+)
+
+// Function that inserts movie or updates the stored movie with the same imdb_id when any imported field changed.
+// Optional columns missing from present keep their stored value, only columns the row carries empty are removed
+func upsertMovie(ctx context.Context, collection *mongo.Collection, movie models.Movie, present map[string]bool, dryRun bool) (upsertOutcome, error) {
+	var existing models.Movie
+
+	err := collection.FindOne(ctx, bson.M{"imdb_id": movie.ImdbID}).Decode(&existing)
+
+	if err == mongo.ErrNoDocuments {
+		if !dryRun {
+			movie.ID = bson.ObjectID{}
+			movie.Archived = false
+			movie.ArchivedAt = nil
+			movie.RatingSum = 0
+			movie.RatingCount = 0
+			movie.RatingAverage = 0
+			//The flag belongs to rankings collection, it is not copied to movies
+			movie.Ranking.ExcludedFromAI = false
+
+			_, err := collection.InsertOne(ctx, movie)
+
+			//Another import or AddMovie inserted the same movie after we looked it up
+			if mongo.IsDuplicateKeyError(err) {
+				return upsertUnchanged, errors.New("imdb_id already exists")
+			}
+
+			if err != nil {
+				return upsertUnchanged, err
+			}
+		}
+		return upsertInserted, nil
+	}
+
+	if err != nil {
+		return upsertUnchanged, err
+	}
+
+	keepStoredMetadata(&movie, existing, present)
+
+	fields := importedFields(movie)
+
+	changed, err := fieldsChanged(importedFields(existing), fields)
+	if err != nil {
+		return upsertUnchanged, err
+	}
+
+	if !changed {
+		return upsertUnchanged, nil
+	}
+
+	if !dryRun {
+		update := bson.M{"$set": fields}
+
+		//Metadata the row carries empty is removed from the stored movie
+		unset := bson.M{}
+		for _, name := range importOptionalFields {
+			if present[name] && !hasField(fields, name) {
+				unset[name] = ""
+			}
+		}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": existing.ID}, update); err != nil {
+			return upsertUnchanged, err
+		}
+	}
+
+	return upsertUpdated, nil
+}
+
+// Function that copies on movie the stored metadata of every optional field the imported row does not carry.
+// Directors and cast of credited movies are built from their credits, rows without credits never change them
+func keepStoredMetadata(movie *models.Movie, existing models.Movie, present map[string]bool) {
+	if !present["release_date"] {
+		movie.ReleaseDate = existing.ReleaseDate
+	}
+	if !present["runtime_minutes"] {
+		movie.RuntimeMinutes = existing.RuntimeMinutes
+	}
+	if !present["synopsis"] {
+		movie.Synopsis = existing.Synopsis
+	}
+	if !present["original_language"] {
+		movie.OriginalLanguage = existing.OriginalLanguage
+	}
+	if !present["spoken_languages"] {
+		movie.SpokenLanguages = existing.SpokenLanguages
+	}
+	if !present["content_rating"] {
+		movie.ContentRating = existing.ContentRating
+	}
+	if !present["type"] {
+		movie.Type = existing.Type
+	}
+	if !present["credits"] {
+		movie.Credits = existing.Credits
+	}
+
+	switch {
+	case present["credits"] && len(movie.Credits) > 0:
+		//Already built from the credits of the row
+	case !present["credits"] && len(existing.Credits) > 0:
+		movie.Directors = existing.Directors
+		movie.Cast = existing.Cast
+	default:
+		if !present["directors"] {
+			movie.Directors = existing.Directors
+		}
+		if !present["cast"] {
+			movie.Cast = existing.Cast
+		}
+	}
+}
+
+// Function that returns the fields of movie written by an import. Empty metadata is left out
+func importedFields(movie models.Movie) bson.D {
+	fields := bson.D{
+		{Key: "title", Value: movie.Title},
+		{Key: "poster_path", Value: movie.PosterPath},
+		{Key: "youtube_id", Value: movie.YoutubeID},
+		{Key: "genre", Value: movie.Genre},
+		{Key: "admin_review", Value: movie.AdminReview},
+		{Key: "ranking", Value: models.Ranking{RankingValue: movie.Ranking.RankingValue, RankingName: movie.Ranking.RankingName}},
+	}
+
+	optional := []bson.E{
+		{Key: "release_date", Value: movie.ReleaseDate},
+		{Key: "runtime_minutes", Value: movie.RuntimeMinutes},
+		{Key: "synopsis", Value: movie.Synopsis},
+		{Key: "original_language", Value: movie.OriginalLanguage},
+		{Key: "spoken_languages", Value: movie.SpokenLanguages},
+		{Key: "content_rating", Value: movie.ContentRating},
+		{Key: "directors", Value: movie.Directors},
+		{Key: "cast", Value: movie.Cast},
+		{Key: "type", Value: movie.Type},
+		{Key: "credits", Value: movie.Credits},
+	}
+
+	for _, field := range optional {
+		value := reflect.ValueOf(field.Value)
+		if value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// Function that reports whether two sets of imported fields hold different values
+func fieldsChanged(stored, imported bson.D) (bool, error) {
+	storedBytes, err := bson.Marshal(stored)
+	if err != nil {
+		return false, err
+	}
+
+	importedBytes, err := bson.Marshal(imported)
+	if err != nil {
+		return false, err
+	}
+
+	return !bytes.Equal(storedBytes, importedBytes), nil
+}
+
+func hasField(fields bson.D, name string) bool {
+	for _, field := range fields {
+		if field.Key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Function that returns an iterator over the data rows of a CSV file. The header row must contain every column of CSVColumns
+func csvRows(reader io.Reader) (func() (*importRow, error), error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read csv header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+
+	for _, name := range CSVColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header is missing column %s", name)
+		}
+	}
+
+	//Every row of the file carries the optional columns of its header
+	present := map[string]bool{}
+	for _, name := range CSVOptionalColumns {
+		if _, ok := columns[name]; ok {
+			present[name] = true
+		}
+	}
+
+	number := 0
+
+	return func() (*importRow, error) {
+		record, err := csvReader.Read()
+
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+
+		number++
+		row := &importRow{number: number, present: present}
+
+		//A malformed line fails only its own row
+		if err != nil {
+			row.err = err
+			return row, nil
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row.movie = models.Movie{
+			ImdbID:      field("imdb_id"),
+			Title:       field("title"),
+			PosterPath:  field("poster_path"),
+			YoutubeID:   field("youtube_id"),
+			AdminReview: field("admin_review"),
+			Ranking:     models.Ranking{RankingName: field("ranking_name")},
+		}
+
+		if value := field("ranking_value"); value != "" {
+			row.movie.Ranking.RankingValue, err = strconv.Atoi(value)
+			if err != nil {
+				row.err = fmt.Errorf("invalid ranking_value %q", value)
+				return row, nil
+			}
+		}
+
+		row.movie.Genre, err = parseGenres(field("genre"))
+		if err != nil {
+			row.err = err
+			return row, nil
+		}
+
+		row.movie.Synopsis = field("synopsis")
+		row.movie.OriginalLanguage = field("original_language")
+		row.movie.ContentRating = field("content_rating")
+		row.movie.SpokenLanguages = splitList(field("spoken_languages"))
+		row.movie.Directors = splitList(field("directors"))
+		row.movie.Cast = splitList(field("cast"))
+		row.movie.Type = field("type")
+
+		if value := field("release_date"); value != "" {
+			releaseDate, err := time.Parse(csvDateLayout, value)
+			if err != nil {
+				row.err = fmt.Errorf("invalid release_date %q, expected YYYY-MM-DD", value)
+				return row, nil
+			}
+			row.movie.ReleaseDate = &releaseDate
+		}
+
+		if value := field("runtime_minutes"); value != "" {
+			row.movie.RuntimeMinutes, err = strconv.Atoi(value)
+			if err != nil {
+				row.err = fmt.Errorf("invalid runtime_minutes %q", value)
+			}
+		}
+
+		return row, nil
+	}, nil
+}
+
+// Function that splits a list written with | separators, empty values are dropped
+func splitList(value string) []string {
+	var items []string
+
+	for _, item := range strings.Split(value, "|") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// Function that parses genres written as id:name pairs separated by | (for example 1:Comedy|2:Drama)
+func parseGenres(value string) ([]models.Genre, error) {
+	genres := []models.Genre{}
+
+	if value == "" {
+		return genres, nil
+	}
+
+	for _, pair := range strings.Split(value, "|") {
+		id, name, ok := strings.Cut(pair, ":")
+
+		if !ok {
+			return nil, fmt.Errorf("invalid genre %q, expected id:name", pair)
+		}
+
+		genreId, err := strconv.Atoi(strings.TrimSpace(id))
+		if err != nil {
+			return nil, fmt.Errorf("invalid genre id %q", id)
+		}
+
+		genres = append(genres, models.Genre{GenreID: genreId, GenreName: strings.TrimSpace(name)})
+	}
+
+	return genres, nil
+}
+
+// Function that returns an iterator over the lines of a newline delimited JSON file, every line is one models.Movie
+func ndjsonRows(reader io.Reader) func() (*importRow, error) {
+	scanner := bufio.NewScanner(reader)
+	//Allow long admin reviews
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	number := 0
+
+	return func() (*importRow, error) {
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+
+			//Blank lines are not rows
+			if line == "" {
+				continue
+			}
+
+			number++
+			row := &importRow{number: number, present: map[string]bool{}}
+
+			if err := json.Unmarshal([]byte(line), &row.movie); err != nil {
+				row.err = fmt.Errorf("invalid json: %w", err)
+				return row, nil
+			}
+
+			//Keys written on the line, null or empty values count as present and clear the stored value
+			var keys map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(line), &keys); err != nil {
+				row.err = fmt.Errorf("invalid json: %w", err)
+				return row, nil
+			}
+
+			for _, name := range importOptionalFields {
+				if _, ok := keys[name]; ok {
+					row.present[name] = true
+				}
+			}
+
+			return row, nil
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+}
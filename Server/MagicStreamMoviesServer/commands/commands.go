@@ -0,0 +1,35 @@
+// Package commands holds the command line subcommands run with `go run . <command>` instead of starting the web server
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Signature every subcommand implements. args does not include the subcommand name
+type command func(client *mongo.Client, args []string) error
+
+var registry = map[string]command{
+	"import":  runImport,
+	"migrate": runMigrate,
+	"seed":    runSeed,
+}
+
+// Function that runs the subcommand named on args[0]
+func Run(client *mongo.Client, args []string) error {
+	cmd, ok := registry[args[0]]
+
+	if !ok {
+		names := make([]string, 0, len(registry))
+		for name := range registry {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command %q, available commands: %s", args[0], strings.Join(names, ", "))
+	}
+
+	return cmd(client, args[1:])
+}
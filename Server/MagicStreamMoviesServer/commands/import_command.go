@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/catalog"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Usage: go run . import -file movies.csv [-format csv|ndjson] [-dry-run]
+// Prints the import report as JSON
+func runImport(client *mongo.Client, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := flags.String("file", "", "path of the CSV or NDJSON file to import")
+	format := flags.String("format", "", "csv or ndjson (defaults to the file extension)")
+	dryRun := flags.Bool("dry-run", false, "validate and classify rows without writing to the database")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *file == "" {
+		return errors.New("import requires -file")
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+		if *format == "jsonl" {
+			*format = catalog.FormatNDJSON
+		}
+	}
+
+	reader, err := os.Open(*file)
+
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	report, err := catalog.ImportMovies(ctx, client, reader, *format, *dryRun)
+
+	if err != nil {
+		return err
+	}
+
+	output, err := json.MarshalIndent(report, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(output))
+
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("%d of %d rows failed", len(report.Failed), report.Total)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/migrations"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Usage: go run . migrate up [-to version] | down [-steps n] | status
+func runMigrate(client *mongo.Client, args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate requires up, down or status")
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	to := flags.Int("to", 0, "apply migrations up to this version (default all)")
+	steps := flags.Int("steps", 1, "amount of migrations to revert")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		ran, err := migrations.Up(ctx, client, *to)
+		for _, migration := range ran {
+			fmt.Printf("applied %04d %s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(ran) == 0 {
+			fmt.Println("database is up to date")
+		}
+		return err
+
+	case "down":
+		reverted, err := migrations.Down(ctx, client, *steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %04d %s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("no migrations to revert")
+		}
+		return err
+
+	case "status":
+		statuses, err := migrations.GetStatus(ctx, client)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.Applied {
+				fmt.Printf("%04d %-30s applied %s\n", status.Version, status.Name, status.AppliedAt.Format(time.RFC3339))
+			} else {
+				fmt.Printf("%04d %-30s pending\n", status.Version, status.Name)
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown migrate command %q, use up, down or status", args[0])
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/seed"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Usage: go run . seed [-version v1] [-admin-email email] [-admin-password password]
+// Admin email and password can also be given with SEED_ADMIN_EMAIL and SEED_ADMIN_PASSWORD environment variables
+func runSeed(client *mongo.Client, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	version := flags.String("version", seed.LatestVersion, "fixtures version to load")
+	adminEmail := flags.String("admin-email", os.Getenv("SEED_ADMIN_EMAIL"), "email of the initial admin user")
+	adminPassword := flags.String("admin-password", os.Getenv("SEED_ADMIN_PASSWORD"), "password of the initial admin user (admin is skipped when empty)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	report, err := seed.Run(ctx, client, seed.Options{
+		Version:       *version,
+		AdminEmail:    *adminEmail,
+		AdminPassword: *adminPassword,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("seeded fixtures %s: %d genres, %d rankings, %d movies, %d users inserted\n",
+		report.Version, report.Genres, report.Rankings, report.Movies, report.Users)
+
+	if *adminPassword == "" {
+		fmt.Println("admin user skipped, set SEED_ADMIN_PASSWORD or -admin-password to create it")
+	}
+
+	return nil
+}
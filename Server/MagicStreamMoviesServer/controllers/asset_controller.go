@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/media"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/storage"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Cache header of files addressed by content hash, they never change so browsers can keep them for a year
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// Function that stores an uploaded poster or backdrop (multipart field "file", form field "kind") with its thumbnails
+// and points the movie poster_path or backdrop_path at it (Admin only)
+func UploadMovieImage(client *mongo.Client, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieId := c.Param("imdb_id")
+
+		kind := c.DefaultPostForm("kind", models.ImageKindPoster)
+		if kind != models.ImageKindPoster && kind != models.ImageKindBackdrop {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be poster or backdrop"})
+			return
+		}
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "An image file is required"})
+			return
+		}
+
+		if fileHeader.Size > media.MaxImageSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image is too large"})
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to read image"})
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.LimitReader(file, media.MaxImageSize))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to read image"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		count, err := movieCollection.CountDocuments(ctx, excludeArchived(bson.M{"imdb_id": movieId}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check movie"})
+			return
+		}
+
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		asset, err := media.SaveImage(ctx, store, data)
+
+		if errors.Is(err, media.ErrUnsupportedImage) {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+			return
+		}
+
+		if errors.Is(err, media.ErrImageTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error(), "max_pixels": media.MaxImagePixels})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store image", "details": err.Error()})
+			return
+		}
+
+		asset.ImdbID = movieId
+		asset.Kind = kind
+		asset.CreatedAt = time.Now()
+
+		var imagesCollection *mongo.Collection = database.OpenCollection("images", client)
+
+		//Uploading the same file again for the same movie refreshes its record instead of adding a new one
+		filter := bson.M{"imdb_id": asset.ImdbID, "kind": asset.Kind, "hash": asset.Hash}
+		opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+
+		if err := imagesCollection.FindOneAndReplace(ctx, filter, asset, opts).Decode(&asset); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image"})
+			return
+		}
+
+		field := "poster_path"
+		if kind == models.ImageKindBackdrop {
+			field = "backdrop_path"
+		}
+
+		if _, err := movieCollection.UpdateOne(ctx, bson.M{"imdb_id": movieId}, bson.M{"$set": bson.M{field: asset.URL}}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movie"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, asset)
+	}
+}
+
+// Function that returns the images uploaded for a movie, newest first
+func GetMovieImages(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var imagesCollection *mongo.Collection = database.OpenCollection("images", client)
+
+		filter := bson.M{"imdb_id": c.Param("imdb_id")}
+		if kind := c.Query("kind"); kind != "" {
+			filter["kind"] = kind
+		}
+
+		cursor, err := imagesCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching images"})
+			return
+		}
+
+		images := []models.ImageAsset{}
+		if err := cursor.All(ctx, &images); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, images)
+	}
+}
+
+// Function that serves the stored images. Their keys contain the content hash so they are cached as immutable
+func ServeImageAsset(store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.Param("key"), "/")
+
+		//Only images are public, videos go through the authenticated streaming routes
+		if !strings.HasPrefix(key, "images/") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+			return
+		}
+
+		c.Header("ETag", `"`+strings.ReplaceAll(strings.TrimPrefix(key, "images/"), "/", "-")+`"`)
+
+		serveBlob(c, store, key, "", immutableCacheControl)
+	}
+}
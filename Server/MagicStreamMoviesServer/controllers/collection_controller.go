@@ -0,0 +1,369 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Error returned when a collection references movies that are not on movies collection
+type unknownMoviesError struct {
+	imdbIds []string
+}
+
+func (e unknownMoviesError) Error() string {
+	return fmt.Sprintf("movies %s do not exist", strings.Join(e.imdbIds, ", "))
+}
+
+// Function that checks every imdb id of a collection belongs to a movie that is not archived
+func checkCollectionMovies(ctx context.Context, client *mongo.Client, imdbIds []string) error {
+	var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+	found, err := movieCollection.Distinct(ctx, "imdb_id", excludeArchived(bson.M{"imdb_id": bson.M{"$in": imdbIds}})).Raw()
+	if err != nil {
+		return err
+	}
+
+	values, err := found.Values()
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]bool{}
+	for _, value := range values {
+		existing[value.StringValue()] = true
+	}
+
+	var missing []string
+	for _, imdbId := range imdbIds {
+		if !existing[imdbId] {
+			missing = append(missing, imdbId)
+		}
+	}
+
+	if len(missing) > 0 {
+		return unknownMoviesError{missing}
+	}
+
+	return nil
+}
+
+// Function that returns every movie of imdbIds that is not archived, keyed by imdb id
+func findVisibleMovies(ctx context.Context, client *mongo.Client, imdbIds []string, projection bson.M) (map[string]models.Movie, error) {
+	var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+	findOptions := options.Find()
+	if projection != nil {
+		findOptions.SetProjection(projection)
+	}
+
+	cursor, err := movieCollection.Find(ctx, excludeArchived(bson.M{"imdb_id": bson.M{"$in": imdbIds}}), findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var movies []models.Movie
+	if err := cursor.All(ctx, &movies); err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]models.Movie, len(movies))
+	for _, movie := range movies {
+		byId[movie.ImdbID] = movie
+	}
+
+	return byId, nil
+}
+
+// Function that returns the collections a movie belongs to with its position and its previous and next entries.
+// Archived movies are skipped so the neighbours are always movies the user can open
+func findCollectionMemberships(ctx context.Context, client *mongo.Client, imdbId string) ([]models.CollectionMembership, error) {
+	memberships := []models.CollectionMembership{}
+
+	var collectionsCollection *mongo.Collection = database.OpenCollection("collections", client)
+
+	cursor, err := collectionsCollection.Find(ctx, bson.M{"movie_ids": imdbId}, options.Find().SetSort(bson.D{{Key: "title", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var collections []models.Collection
+	if err := cursor.All(ctx, &collections); err != nil {
+		return nil, err
+	}
+
+	if len(collections) == 0 {
+		return memberships, nil
+	}
+
+	var imdbIds []string
+	for _, collection := range collections {
+		imdbIds = append(imdbIds, collection.MovieIDs...)
+	}
+
+	movies, err := findVisibleMovies(ctx, client, imdbIds, bson.M{"imdb_id": 1, "title": 1, "poster_path": 1})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, collection := range collections {
+		var visible []string
+		for _, id := range collection.MovieIDs {
+			if _, ok := movies[id]; ok {
+				visible = append(visible, id)
+			}
+		}
+
+		for i, id := range visible {
+			if id != imdbId {
+				continue
+			}
+
+			membership := models.CollectionMembership{
+				CollectionID: collection.CollectionID,
+				Title:        collection.Title,
+				ArtworkPath:  collection.ArtworkPath,
+				Position:     i + 1,
+				Total:        len(visible),
+			}
+
+			if i > 0 {
+				membership.Previous = collectionEntry(movies[visible[i-1]])
+			}
+
+			if i < len(visible)-1 {
+				membership.Next = collectionEntry(movies[visible[i+1]])
+			}
+
+			memberships = append(memberships, membership)
+		}
+	}
+
+	return memberships, nil
+}
+
+func collectionEntry(movie models.Movie) *models.CollectionEntry {
+	return &models.CollectionEntry{ImdbID: movie.ImdbID, Title: movie.Title, PosterPath: movie.PosterPath}
+}
+
+// Function that returns every collection sorted by title
+func GetCollections(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var collectionsCollection *mongo.Collection = database.OpenCollection("collections", client)
+
+		cursor, err := collectionsCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "title", Value: 1}}))
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching collections"})
+			return
+		}
+
+		collections := []models.Collection{}
+		if err := cursor.All(ctx, &collections); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, collections)
+	}
+}
+
+// Function that returns a collection with its movies in watching order
+func GetCollection(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var collectionsCollection *mongo.Collection = database.OpenCollection("collections", client)
+
+		var details models.CollectionDetails
+
+		if err := collectionsCollection.FindOne(ctx, bson.M{"collection_id": c.Param("collection_id")}).Decode(&details.Collection); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+			return
+		}
+
+		movies, err := findVisibleMovies(ctx, client, details.Collection.MovieIDs, nil)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching collection movies"})
+			return
+		}
+
+		details.Movies = []models.Movie{}
+		for _, id := range details.Collection.MovieIDs {
+			if movie, ok := movies[id]; ok {
+				details.Movies = append(details.Movies, movie)
+			}
+		}
+
+		c.JSON(http.StatusOK, details)
+	}
+}
+
+// Function that adds a collection (Admin only)
+func CreateCollection(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var collection models.Collection
+
+		if err := c.ShouldBindJSON(&collection); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		if err := validate.Struct(collection); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		if err := checkCollectionMovies(ctx, client, collection.MovieIDs); err != nil {
+			respondCollectionError(c, err)
+			return
+		}
+
+		collection.ID = bson.ObjectID{}
+		collection.CollectionID = bson.NewObjectID().Hex()
+		collection.CreatedAt = time.Now()
+		collection.UpdatedAt = time.Now()
+
+		var collectionsCollection *mongo.Collection = database.OpenCollection("collections", client)
+
+		result, err := collectionsCollection.InsertOne(ctx, collection)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add collection"})
+			return
+		}
+
+		collection.ID, _ = result.InsertedID.(bson.ObjectID)
+
+		c.JSON(http.StatusCreated, collection)
+	}
+}
+
+// Function that partially updates a collection (Admin only). A new movie_ids list replaces the whole order
+func UpdateCollection(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collectionId := c.Param("collection_id")
+
+		var update models.CollectionUpdate
+
+		if err := c.ShouldBindJSON(&update); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var collectionsCollection *mongo.Collection = database.OpenCollection("collections", client)
+
+		var collection models.Collection
+
+		if err := collectionsCollection.FindOne(ctx, bson.M{"collection_id": collectionId}).Decode(&collection); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+			return
+		}
+
+		set := bson.M{}
+
+		if update.Title != nil {
+			collection.Title = *update.Title
+			set["title"] = collection.Title
+		}
+
+		if update.Description != nil {
+			collection.Description = *update.Description
+			set["description"] = collection.Description
+		}
+
+		if update.ArtworkPath != nil {
+			collection.ArtworkPath = *update.ArtworkPath
+			set["artwork_path"] = collection.ArtworkPath
+		}
+
+		if update.MovieIDs != nil {
+			collection.MovieIDs = *update.MovieIDs
+			set["movie_ids"] = collection.MovieIDs
+		}
+
+		if len(set) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+			return
+		}
+
+		if err := validate.Struct(collection); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		if update.MovieIDs != nil {
+			if err := checkCollectionMovies(ctx, client, collection.MovieIDs); err != nil {
+				respondCollectionError(c, err)
+				return
+			}
+		}
+
+		collection.UpdatedAt = time.Now()
+		set["updated_at"] = collection.UpdatedAt
+
+		if _, err := collectionsCollection.UpdateOne(ctx, bson.M{"collection_id": collectionId}, bson.M{"$set": set}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating collection"})
+			return
+		}
+
+		c.JSON(http.StatusOK, collection)
+	}
+}
+
+// Function that deletes a collection (Admin only). Its movies are not modified
+func DeleteCollection(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var collectionsCollection *mongo.Collection = database.OpenCollection("collections", client)
+
+		result, err := collectionsCollection.DeleteOne(ctx, bson.M{"collection_id": c.Param("collection_id")})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete collection"})
+			return
+		}
+
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Collection deleted"})
+	}
+}
+
+// Function that writes the response when the movies of a collection could not be checked
+func respondCollectionError(c *gin.Context, err error) {
+	var unknown unknownMoviesError
+
+	if errors.As(err, &unknown) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": unknown.Error()})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check collection movies"})
+}
@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Errors returned from genre transactions and mapped to http status codes
+var errGenreNotFound = errors.New("genre not found")
+var errReplacementNotFound = errors.New("replacement genre not found")
+var errGenreInUse = errors.New("genre is used by movies or users, a replacement genre is required")
+
+// Function that adds a genre to genres collection (Admin only)
+func CreateGenre(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var genre models.Genre
+
+		if err := c.ShouldBindJSON(&genre); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		if err := validate.Struct(genre); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var genreCollection *mongo.Collection = database.OpenCollection("genres", client)
+
+		_, err := genreCollection.InsertOne(ctx, genre)
+
+		//Genre ids and names are unique (unique indexes created by migrations)
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Genre already exists"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add genre"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, genre)
+	}
+}
+
+// Function that renames a genre and every copy of it embedded on movies and users inside a transaction (Admin only)
+func RenameGenre(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		genreId, err := strconv.Atoi(c.Param("genre_id"))
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid genre id"})
+			return
+		}
+
+		var req models.GenreRename
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		err = runInTransaction(ctx, client, func(ctx context.Context) error {
+			var genreCollection *mongo.Collection = database.OpenCollection("genres", client)
+
+			result, err := genreCollection.UpdateOne(ctx, bson.M{"genre_id": genreId}, bson.M{"$set": bson.M{"genre_name": req.GenreName}})
+			if err != nil {
+				return err
+			}
+
+			if result.MatchedCount == 0 {
+				return errGenreNotFound
+			}
+
+			//Rename only the array elements holding this genre
+			arrayFilters := options.UpdateMany().SetArrayFilters([]any{bson.M{"g.genre_id": genreId}})
+
+			var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+			_, err = movieCollection.UpdateMany(ctx,
+				bson.M{"genre.genre_id": genreId},
+				bson.M{"$set": bson.M{"genre.$[g].genre_name": req.GenreName}},
+				arrayFilters)
+			if err != nil {
+				return err
+			}
+
+			var userCollection *mongo.Collection = database.OpenCollection("users", client)
+			_, err = userCollection.UpdateMany(ctx,
+				bson.M{"favourite_genres.genre_id": genreId},
+				bson.M{"$set": bson.M{"favourite_genres.$[g].genre_name": req.GenreName}},
+				arrayFilters)
+
+			return err
+		})
+
+		if err != nil {
+			respondGenreError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, models.Genre{GenreID: genreId, GenreName: req.GenreName})
+	}
+}
+
+// Function that deletes a genre (Admin only). A genre used by movies or users is only deleted when a replacement_id
+// query parameter is given, every embedded copy is then swapped for the replacement inside a transaction
+func DeleteGenre(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		genreId, err := strconv.Atoi(c.Param("genre_id"))
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid genre id"})
+			return
+		}
+
+		var replacementId *int
+		if value := c.Query("replacement_id"); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil || id == genreId {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid replacement genre id"})
+				return
+			}
+			replacementId = &id
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		err = runInTransaction(ctx, client, func(ctx context.Context) error {
+			var genreCollection *mongo.Collection = database.OpenCollection("genres", client)
+			var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+			var userCollection *mongo.Collection = database.OpenCollection("users", client)
+
+			result, err := genreCollection.DeleteOne(ctx, bson.M{"genre_id": genreId})
+			if err != nil {
+				return err
+			}
+
+			if result.DeletedCount == 0 {
+				return errGenreNotFound
+			}
+
+			if replacementId == nil {
+				movies, err := movieCollection.CountDocuments(ctx, bson.M{"genre.genre_id": genreId})
+				if err != nil {
+					return err
+				}
+
+				users, err := userCollection.CountDocuments(ctx, bson.M{"favourite_genres.genre_id": genreId})
+				if err != nil {
+					return err
+				}
+
+				//Aborting the transaction also restores the deleted genre
+				if movies > 0 || users > 0 {
+					return errGenreInUse
+				}
+
+				return nil
+			}
+
+			var replacement models.Genre
+			err = genreCollection.FindOne(ctx, bson.M{"genre_id": *replacementId}).Decode(&replacement)
+			if err == mongo.ErrNoDocuments {
+				return errReplacementNotFound
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := replaceEmbeddedGenre(ctx, movieCollection, "genre", genreId, replacement); err != nil {
+				return err
+			}
+
+			return replaceEmbeddedGenre(ctx, userCollection, "favourite_genres", genreId, replacement)
+		})
+
+		if err != nil {
+			respondGenreError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Genre deleted"})
+	}
+}
+
+// Function that swaps genre genreId for replacement on the genres array field of every document of collection.
+// Documents that already have the replacement just lose the deleted genre so the replacement is not repeated
+func replaceEmbeddedGenre(ctx context.Context, collection *mongo.Collection, field string, genreId int, replacement models.Genre) error {
+	_, err := collection.UpdateMany(ctx,
+		bson.M{field + ".genre_id": bson.M{"$all": bson.A{genreId, replacement.GenreID}}},
+		bson.M{"$pull": bson.M{field: bson.M{"genre_id": genreId}}})
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateMany(ctx,
+		bson.M{field + ".genre_id": genreId},
+		bson.M{"$set": bson.M{field + ".$[g]": replacement}},
+		options.UpdateMany().SetArrayFilters([]any{bson.M{"g.genre_id": genreId}}))
+
+	return err
+}
+
+// Function that runs fn inside a MongoDB transaction, changes are only committed when fn returns nil.
+// Transactions require MongoDB to run as a replica set
+func runInTransaction(ctx context.Context, client *mongo.Client, fn func(ctx context.Context) error) error {
+	session, err := client.StartSession()
+
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+
+	return err
+}
+
+// Function that writes the response of a failed genre change
+func respondGenreError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errGenreNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Genre not found"})
+	case errors.Is(err, errReplacementNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Replacement genre not found"})
+	case errors.Is(err, errGenreInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case mongo.IsDuplicateKeyError(err):
+		c.JSON(http.StatusConflict, gin.H{"error": "Genre name already exists"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating genre", "details": err.Error()})
+	}
+}
@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Events of the same movie closer than this are merged on a single history entry
+const historySessionGap = 30 * time.Minute
+
+// Function that adds an event to the watch history of a user unless the user paused it. An event of the same kind and
+// movie recorded less than historySessionGap ago only moves that entry forward
+func recordHistory(ctx context.Context, client *mongo.Client, userId, movieId, event string) error {
+	var userCollection *mongo.Collection = database.OpenCollection("users", client)
+
+	var user models.User
+	err := userCollection.FindOne(ctx, bson.M{"user_id": userId}, options.FindOne().SetProjection(bson.M{"history_paused": 1})).Decode(&user)
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	if user.HistoryPaused {
+		return nil
+	}
+
+	var historyCollection *mongo.Collection = database.OpenCollection("history", client)
+
+	now := time.Now()
+
+	result, err := historyCollection.UpdateOne(ctx,
+		bson.M{"user_id": userId, "imdb_id": movieId, "event": event, "last_at": bson.M{"$gte": now.Add(-historySessionGap)}},
+		bson.M{"$set": bson.M{"last_at": now}})
+
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	_, err = historyCollection.InsertOne(ctx, models.HistoryEntry{
+		UserID:    userId,
+		ImdbID:    movieId,
+		Event:     event,
+		StartedAt: now,
+		LastAt:    now,
+	})
+
+	return err
+}
+
+// Function that records a history event from a request handler. History is secondary so failures are only logged
+func recordHistoryFromRequest(ctx context.Context, c *gin.Context, client *mongo.Client, movieId, event string) {
+	userId, err := utils.GetUserIdFromContext(c)
+	if err != nil {
+		return
+	}
+
+	if err := recordHistory(ctx, client, userId, movieId, event); err != nil {
+		log.Println("Warning: unable to record watch history:", err)
+	}
+}
+
+// Function that returns the imdb ids of the movies the user played
+func findWatchedMovieIds(ctx context.Context, client *mongo.Client, userId string) ([]string, error) {
+	var historyCollection *mongo.Collection = database.OpenCollection("history", client)
+
+	result := historyCollection.Distinct(ctx, "imdb_id", bson.M{"user_id": userId, "event": models.HistoryEventWatched})
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	watchedIds := []string{}
+	err := result.Decode(&watchedIds)
+
+	return watchedIds, err
+}
+
+// Function that returns one page of the watch history of the current user, most recent first
+func GetHistory(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		var query models.PageQuery
+
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+			return
+		}
+
+		if err := validate.Struct(query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		keysetQuery, err := utils.NewKeysetQuery("last_at", false, query.Limit, query.Cursor)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var historyCollection *mongo.Collection = database.OpenCollection("history", client)
+
+		stages := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"user_id": userId}}},
+			//Entries of archived movies are hidden
+			{{Key: "$lookup", Value: bson.M{
+				"from":     "movies",
+				"let":      bson.M{"imdb_id": "$imdb_id"},
+				"pipeline": bson.A{bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$imdb_id", "$$imdb_id"}}, "archived": bson.M{"$ne": true}}}},
+				"as":       "movie",
+			}}},
+			{{Key: "$unwind", Value: "$movie"}},
+		}
+
+		page, err := utils.AggregatePage[models.HistoryItem](ctx, historyCollection, stages, keysetQuery)
+
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// Function that deletes one entry of the watch history of the current user
+func DeleteHistoryEntry(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		entryId, err := bson.ObjectIDFromHex(c.Param("entry_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid history entry id"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var historyCollection *mongo.Collection = database.OpenCollection("history", client)
+
+		//Filtering by user too so nobody can delete entries of other users
+		result, err := historyCollection.DeleteOne(ctx, bson.M{"_id": entryId, "user_id": userId})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete history entry"})
+			return
+		}
+
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "History entry not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "History entry deleted"})
+	}
+}
+
+// Function that deletes the whole watch history of the current user
+func ClearHistory(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var historyCollection *mongo.Collection = database.OpenCollection("history", client)
+
+		result, err := historyCollection.DeleteMany(ctx, bson.M{"user_id": userId})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "History cleared", "deleted": result.DeletedCount})
+	}
+}
+
+// Function that pauses or resumes the recording of the watch history of the current user
+func UpdateHistorySettings(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		var settings models.HistorySettings
+
+		if err := c.ShouldBindJSON(&settings); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		if err := validate.Struct(settings); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var userCollection *mongo.Collection = database.OpenCollection("users", client)
+
+		result, err := userCollection.UpdateOne(ctx, bson.M{"user_id": userId}, bson.M{"$set": bson.M{"history_paused": *settings.Paused, "updated_at": time.Now()}})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update history settings"})
+			return
+		}
+
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/catalog"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Function that returns filter with an extra condition hiding soft deleted movies.
+// Documents inserted before soft delete existed have no archived field so we check for not true instead of false
+func excludeArchived(filter bson.M) bson.M {
+	filter["archived"] = bson.M{"$ne": true}
+	return filter
+}
+
+// Function that partially updates title, poster, trailer, genres, ranking and metadata of a movie. Only admins can reach it
+func UpdateMovie(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieId := c.Param("imdb_id")
+
+		if movieId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Movie ID is required"})
+			return
+		}
+
+		var update models.MovieUpdate
+
+		if err := c.ShouldBindJSON(&update); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		var movie models.Movie
+
+		err := movieCollection.FindOne(ctx, excludeArchived(bson.M{"imdb_id": movieId})).Decode(&movie)
+
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		//Apply received fields on stored movie so the result is validated with the same rules used when adding movies
+		set := bson.M{}
+
+		if update.Title != nil {
+			movie.Title = *update.Title
+			set["title"] = movie.Title
+		}
+
+		if update.PosterPath != nil {
+			movie.PosterPath = *update.PosterPath
+			set["poster_path"] = movie.PosterPath
+		}
+
+		if update.BackdropPath != nil {
+			movie.BackdropPath = *update.BackdropPath
+			set["backdrop_path"] = movie.BackdropPath
+		}
+
+		if update.YoutubeID != nil {
+			movie.YoutubeID = *update.YoutubeID
+			set["youtube_id"] = movie.YoutubeID
+		}
+
+		if update.Genre != nil {
+			movie.Genre = *update.Genre
+			set["genre"] = movie.Genre
+		}
+
+		if update.Ranking != nil {
+			movie.Ranking = *update.Ranking
+			//The flag belongs to rankings collection, it is not copied to movies
+			movie.Ranking.ExcludedFromAI = false
+			set["ranking"] = movie.Ranking
+		}
+
+		if update.Type != nil {
+			movie.Type = *update.Type
+			set["type"] = movie.Type
+		}
+
+		if update.ReleaseDate != nil {
+			movie.ReleaseDate = update.ReleaseDate
+			set["release_date"] = movie.ReleaseDate
+		}
+
+		if update.RuntimeMinutes != nil {
+			movie.RuntimeMinutes = *update.RuntimeMinutes
+			set["runtime_minutes"] = movie.RuntimeMinutes
+		}
+
+		if update.Synopsis != nil {
+			movie.Synopsis = *update.Synopsis
+			set["synopsis"] = movie.Synopsis
+		}
+
+		if update.OriginalLanguage != nil {
+			movie.OriginalLanguage = *update.OriginalLanguage
+			set["original_language"] = movie.OriginalLanguage
+		}
+
+		if update.SpokenLanguages != nil {
+			movie.SpokenLanguages = *update.SpokenLanguages
+			set["spoken_languages"] = movie.SpokenLanguages
+		}
+
+		if update.ContentRating != nil {
+			movie.ContentRating = *update.ContentRating
+			set["content_rating"] = movie.ContentRating
+		}
+
+		if update.Directors != nil {
+			movie.Directors = *update.Directors
+			set["directors"] = movie.Directors
+		}
+
+		if update.Cast != nil {
+			movie.Cast = *update.Cast
+			set["cast"] = movie.Cast
+		}
+
+		if update.Credits != nil {
+			movie.Credits = *update.Credits
+			set["credits"] = movie.Credits
+
+			//Credited people must exist, their names replace directors and cast
+			if err := utils.ApplyCredits(ctx, client, &movie); err != nil {
+				respondCreditsError(c, err)
+				return
+			}
+
+			if len(movie.Credits) > 0 {
+				set["directors"] = movie.Directors
+				set["cast"] = movie.Cast
+			}
+		}
+
+		if len(set) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+			return
+		}
+
+		if err := validate.Struct(movie); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		//Ranking must be one of the rankings stored on rankings collection
+		if update.Ranking != nil {
+			var rankingsCollection *mongo.Collection = database.OpenCollection("rankings", client)
+
+			count, err := rankingsCollection.CountDocuments(ctx, bson.M{
+				"ranking_value": movie.Ranking.RankingValue,
+				"ranking_name":  movie.Ranking.RankingName,
+			})
+
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check ranking"})
+				return
+			}
+
+			if count == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Ranking does not exist"})
+				return
+			}
+		}
+
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+		var updated models.Movie
+		err = movieCollection.FindOneAndUpdate(ctx, excludeArchived(bson.M{"imdb_id": movieId}), bson.M{"$set": set}, opts).Decode(&updated)
+
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating movie"})
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// Function that soft deletes a movie. The document is kept but hidden from every listing until it is restored
+func DeleteMovie(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setMovieArchived(c, client, true)
+	}
+}
+
+// Function that restores a soft deleted movie
+func RestoreMovie(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setMovieArchived(c, client, false)
+	}
+}
+
+// Function that archives or restores the movie given on the route
+func setMovieArchived(c *gin.Context, client *mongo.Client, archived bool) {
+	movieId := c.Param("imdb_id")
+
+	if movieId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Movie ID is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c, 100*time.Second)
+	defer cancel()
+
+	var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+	update := bson.M{"$set": bson.M{"archived": false}, "$unset": bson.M{"archived_at": ""}}
+	if archived {
+		update = bson.M{"$set": bson.M{"archived": true, "archived_at": time.Now()}}
+	}
+
+	result, err := movieCollection.UpdateOne(ctx, bson.M{"imdb_id": movieId}, update)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating movie"})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+
+	if archived {
+		c.JSON(http.StatusOK, gin.H{"message": "Movie archived"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Movie restored"})
+}
+
+// Maximum size of an uploaded import file
+const maxImportSize = 32 << 20
+
+// Function that bulk imports movies from a CSV or NDJSON request body (format and dry_run query parameters)
+// and returns a report with inserted, updated, skipped and failed rows
+func ImportMovies(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.Query("format")
+
+		//Fall back to content type when format is not given
+		if format == "" {
+			switch c.ContentType() {
+			case "text/csv":
+				format = catalog.FormatCSV
+			case "application/x-ndjson":
+				format = catalog.FormatNDJSON
+			}
+		}
+
+		if format != catalog.FormatCSV && format != catalog.FormatNDJSON {
+			c.JSON(http.StatusBadRequest, gin.H{"error": catalog.ErrUnsupportedFormat.Error()})
+			return
+		}
+
+		dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+		report, err := catalog.ImportMovies(ctx, client, body, format, dryRun)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Import failed", "details": err.Error(), "report": report})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// Function that streams the whole catalog as NDJSON, CSV or JSON-LD (format query parameter) straight from the db cursor
+func ExportMovies(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", catalog.FormatNDJSON)
+
+		contentType, extension, ok := catalog.ExportFormat(format)
+
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported export format, use ndjson, csv or jsonld"})
+			return
+		}
+
+		//No timeout for the db query since the export lasts as long as the client takes to read it
+		ctx := c.Request.Context()
+
+		cursor, err := catalog.OpenExportCursor(ctx, client)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies."})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", "attachment; filename=movies."+extension)
+		c.Status(http.StatusOK)
+
+		//Status was already sent, errors can only be logged and the response cut short
+		if err := catalog.WriteExport(ctx, cursor, c.Writer, format); err != nil {
+			log.Println("Error exporting movies:", err)
+			c.Abort()
+		}
+	}
+}
@@ -6,12 +6,13 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"regexp"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
 	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/sentiment"
 	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -20,8 +21,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-
-	"github.com/tmc/langchaingo/llms/openai"
 )
 
 // Validator
@@ -35,6 +34,26 @@ var validate = validator.New()
 func GetMovies(client *mongo.Client) gin.HandlerFunc {
 	//c is context of http request, ctx is request of database operation/query
 	return func(c *gin.Context) {
+		//Read filters, sort and pagination parameters from query string
+		var query models.MovieQuery
+
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+			return
+		}
+
+		if err := validate.Struct(query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		keysetQuery, err := utils.NewKeysetQuery(movieSortFields[query.Sort], query.Order != "desc", query.Limit, query.Cursor)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+
 		//Execute Query with Timeout of 100 seconds (these 2 lines are for memory management)
 		ctx, cancel := context.WithTimeout(c, 100*time.Second)
 		defer cancel()
@@ -42,28 +61,136 @@ func GetMovies(client *mongo.Client) gin.HandlerFunc {
 		// Get collection
 		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
 
-		//Create array of type Movie struct defined on models package and inside movie_model file
-		var movies []models.Movie
+		//Query one page of movies
+		page, err := utils.FindPage[models.Movie](ctx, movieCollection, buildMovieFilter(query.MovieFilters), keysetQuery)
 
-		//Cursor that queries the db
-		cursor, err := movieCollection.Find(ctx, bson.M{})
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor does not match sort option"})
+			return
+		}
 
 		//Error that occurs when we can't fecth movies from db
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies."})
+			return
+		}
+
+		movies := make([]*models.Movie, len(page.Items))
+		for i := range page.Items {
+			movies[i] = &page.Items[i]
 		}
-		//Line for memory management
-		defer cursor.Close(ctx)
 
-		//Error that occurs when we can covert movies from db into movies array structure elements
-		if err := cursor.All(ctx, &movies); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode movies."})
+		if err := personalizeMovies(ctx, c, client, movies...); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user data"})
+			return
 		}
 
-		c.JSON(http.StatusOK, movies)
+		c.JSON(http.StatusOK, page)
 	}
 }
 
+// Sort options of movies listing mapped to the document field they sort by. Insertion date is the _id timestamp
+var movieSortFields = map[string]string{
+	"":        "_id",
+	"created": "_id",
+	"title":   "title",
+	"ranking": "ranking.ranking_value",
+	"release": "release_date",
+}
+
+// Function that builds the movies collection filter from the listing and search query parameters
+func buildMovieFilter(query models.MovieFilters) bson.M {
+	filter := excludeArchived(bson.M{})
+
+	if query.Genre != "" {
+		filter["genre.genre_name"] = query.Genre
+	}
+
+	if query.RankingValue != nil {
+		filter["ranking.ranking_value"] = *query.RankingValue
+	}
+
+	if query.RankingName != "" {
+		filter["ranking.ranking_name"] = query.RankingName
+	}
+
+	//Anchored and case sensitive so the title index can be used
+	if query.Title != "" {
+		filter["title"] = bson.M{"$regex": "^" + regexp.QuoteMeta(query.Title)}
+	}
+
+	//Release years are inclusive, year_to includes the whole last year
+	releaseDate := bson.M{}
+	if query.YearFrom != 0 {
+		releaseDate["$gte"] = time.Date(query.YearFrom, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if query.YearTo != 0 {
+		releaseDate["$lt"] = time.Date(query.YearTo+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if len(releaseDate) > 0 {
+		filter["release_date"] = releaseDate
+	}
+
+	runtime := bson.M{}
+	if query.RuntimeMin != 0 {
+		runtime["$gte"] = query.RuntimeMin
+	}
+	if query.RuntimeMax != 0 {
+		runtime["$lte"] = query.RuntimeMax
+	}
+	if len(runtime) > 0 {
+		filter["runtime_minutes"] = runtime
+	}
+
+	if query.Language != "" {
+		filter["$or"] = bson.A{
+			bson.M{"original_language": query.Language},
+			bson.M{"spoken_languages": query.Language},
+		}
+	}
+
+	if query.ContentRating != "" {
+		filter["content_rating"] = query.ContentRating
+	}
+
+	if query.Director != "" {
+		filter["directors"] = query.Director
+	}
+
+	if query.Cast != "" {
+		filter["cast"] = query.Cast
+	}
+
+	//Films stored before series existed have no type
+	switch query.Type {
+	case models.ContentTypeSeries:
+		filter["type"] = models.ContentTypeSeries
+	case models.ContentTypeMovie:
+		filter["type"] = bson.M{"$ne": models.ContentTypeSeries}
+	}
+
+	//Both person and role must match the same credit
+	switch {
+	case query.Person != "" && query.PersonRole != "":
+		filter["credits"] = bson.M{"$elemMatch": bson.M{"person_id": query.Person, "role": query.PersonRole}}
+	case query.Person != "":
+		filter["credits.person_id"] = query.Person
+	case query.PersonRole != "":
+		filter["credits.role"] = query.PersonRole
+	}
+
+	return filter
+}
+
+// Function that fills the per user fields of movies (watchlist flag and own rating) for the authenticated user
+func personalizeMovies(ctx context.Context, c *gin.Context, client *mongo.Client, movies ...*models.Movie) error {
+	if err := markWatchlist(ctx, c, client, movies...); err != nil {
+		return err
+	}
+
+	return markRatings(ctx, c, client, movies...)
+}
+
 // Function that returns a single movie from DB given IMDB_ID
 func GetMovie(client *mongo.Client) gin.HandlerFunc {
 	//c is context of http request, ctx is request of database operation/query
@@ -87,16 +214,31 @@ func GetMovie(client *mongo.Client) gin.HandlerFunc {
 		// Get collection
 		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
 
-		var movie models.Movie
+		var movie models.MovieDetails
 
 		//Fetch movie from db and store it in movie var
-		err := movieCollection.FindOne(ctx, bson.M{"imdb_id": movieID}).Decode(&movie)
+		err := movieCollection.FindOne(ctx, excludeArchived(bson.M{"imdb_id": movieID})).Decode(&movie)
 
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
 			return
 		}
 
+		//Collections the movie belongs to with the previous and next movie of each one
+		movie.Collections, err = findCollectionMemberships(ctx, client, movieID)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching movie collections"})
+			return
+		}
+
+		if err := personalizeMovies(ctx, c, client, &movie.Movie); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user data"})
+			return
+		}
+
+		recordHistoryFromRequest(ctx, c, client, movieID, models.HistoryEventViewed)
+
 		c.JSON(http.StatusOK, movie)
 
 	}
@@ -126,9 +268,31 @@ func AddMovie(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
+		//New movies are never archived and have no user ratings yet
+		movie.Archived = false
+		movie.ArchivedAt = nil
+		movie.RatingSum = 0
+		movie.RatingCount = 0
+		movie.RatingAverage = 0
+
+		//The flag belongs to rankings collection, it is not copied to movies
+		movie.Ranking.ExcludedFromAI = false
+
+		//Credited people must exist, their names fill directors and cast
+		if err := utils.ApplyCredits(ctx, client, &movie); err != nil {
+			respondCreditsError(c, err)
+			return
+		}
+
 		//Insert input into movie collection in db
 		result, err := movieCollection.InsertOne(ctx, movie)
 
+		//imdb_id is unique on movies collection (unique index created by migrations)
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Movie already exists"})
+			return
+		}
+
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add movie"})
 			return
@@ -140,7 +304,7 @@ func AddMovie(client *mongo.Client) gin.HandlerFunc {
 }
 
 // Function to update movie review on db
-func AdminReviewUpdate(client *mongo.Client) gin.HandlerFunc {
+func AdminReviewUpdate(client *mongo.Client, classifier sentiment.Classifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		//Check if user has role admin
 
@@ -171,8 +335,10 @@ func AdminReviewUpdate(client *mongo.Client) gin.HandlerFunc {
 		//"admin_review"  : "Clint Eastwood as always was magnificent. What an amazing cast and movie"
 		//Response from the admin is it's review and the ranking name coming from OpenAI sentiment analysis
 		var resp struct {
-			RankingName string `json:"ranking_name"`
-			AdminReview string `json:"admin_review"`
+			RankingName string  `json:"ranking_name"`
+			AdminReview string  `json:"admin_review"`
+			Confidence  float64 `json:"confidence"` //How sure the AI is about the ranking, from 0 to 1
+			Rationale   string  `json:"rationale"`  //Why the AI chose the ranking
 		}
 
 		if err := c.ShouldBind(&req); err != nil {
@@ -181,23 +347,30 @@ func AdminReviewUpdate(client *mongo.Client) gin.HandlerFunc {
 		}
 
 		//Get response from AI given movie review
-		sentiment, rankVal, err := GetReviewRanking(req.AdminReview, client, c)
+		classification, err := GetReviewRanking(req.AdminReview, classifier, client, c)
+
+		//The AI kept answering rankings that do not exist, nothing is stored
+		var invalidOutput *sentiment.InvalidOutputError
+		if errors.As(err, &invalidOutput) {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "AI returned an invalid ranking", "details": err.Error()})
+			return
+		}
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting review ranking", "details": err.Error()})
 			return
 		}
 
-		//Filter to find movie document in movies collection
-		filter := bson.M{"imdb_id": movieId}
+		//Filter to find movie document in movies collection, archived movies can not be reviewed
+		filter := excludeArchived(bson.M{"imdb_id": movieId})
 
 		//Bson (Database) content to be updated on document
 		update := bson.M{
 			"$set": bson.M{
 				"admin_review": req.AdminReview,
 				"ranking": bson.M{
-					"ranking_value": rankVal,
-					"ranking_name":  sentiment,
+					"ranking_value": classification.Ranking.RankingValue,
+					"ranking_name":  classification.Ranking.RankingName,
 				},
 			},
 		}
@@ -220,80 +393,27 @@ func AdminReviewUpdate(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
-		resp.RankingName = sentiment
+		resp.RankingName = classification.Ranking.RankingName
 		resp.AdminReview = req.AdminReview
+		resp.Confidence = classification.Confidence
+		resp.Rationale = classification.Rationale
 
 		c.JSON(http.StatusOK, resp)
 
 	}
 }
 
-// Function that prompts AI given a movie review and returns the ranking name and ranking vale and an error if occurs
-func GetReviewRanking(admin_review string, client *mongo.Client, c *gin.Context) (string, int, error) {
+// Function that asks the classifier for the ranking of a movie review. The ranking is always one of the rankings not
+// excluded from AI, a model that keeps answering invalid output fails with *sentiment.InvalidOutputError
+func GetReviewRanking(admin_review string, classifier sentiment.Classifier, client *mongo.Client, c *gin.Context) (sentiment.Classification, error) {
 	//Get rakings collection from db
 	rankings, err := GetRankings(client, c)
 
 	if err != nil {
-		return "", 0, err
-	}
-
-	sentimentDelimited := ""
-
-	//Get all ranking names in one string
-	for _, ranking := range rankings {
-		if ranking.RankingValue != 999 {
-			sentimentDelimited = sentimentDelimited + ranking.RankingName + ","
-		}
+		return sentiment.Classification{}, err
 	}
 
-	//Format
-	sentimentDelimited = strings.Trim(sentimentDelimited, ",")
-
-	//Load env file and prompt variable
-	err = godotenv.Load(".env")
-
-	if err != nil {
-		log.Println("Warning: .env file not found")
-	}
-
-	//Get open ai api key from env
-	OpenAiApiKey := os.Getenv("OPENAI_API_KEY")
-
-	if OpenAiApiKey == "" {
-		return "", 0, errors.New("could not read open ai api key")
-	}
-
-	//Sign to open ai with our key
-	llm, err := openai.New(openai.WithToken(OpenAiApiKey))
-
-	if err != nil {
-		return "", 0, err
-	}
-
-	//Get base prompt from env
-	base_prompt_template := os.Getenv("BASE_PROMPT_TEMPLATE")
-
-	//include rankings in base prompt
-	base_prompt := strings.Replace(base_prompt_template, "{rankings}", sentimentDelimited, 1)
-
-	//pass prompt to llm and concatenate movie review to base_prompt var
-	response, err := llm.Call(context.Background(), base_prompt+admin_review)
-
-	if err != nil {
-		return "", 0, err
-	}
-	//Get ranking from prompt
-	rank_val := 0
-
-	for _, ranking := range rankings {
-		if ranking.RankingName == response {
-			rank_val = ranking.RankingValue
-			break
-		}
-	}
-
-	return response, rank_val, nil
-
+	return classifier.Classify(c, admin_review, rankings)
 }
 
 // Function that queries and returns all rankings from rankings collection from db
@@ -305,8 +425,8 @@ func GetRankings(client *mongo.Client, c *gin.Context) ([]models.Ranking, error)
 
 	var rankingsCollection *mongo.Collection = database.OpenCollection("rankings", client)
 
-	//Cursor that queries the rankings collection
-	cursor, err := rankingsCollection.Find(ctx, bson.M{})
+	//Cursor that queries the rankings collection, best rankings first
+	cursor, err := rankingsCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "ranking_value", Value: 1}}))
 
 	//Error that occurs when we can't fecth rankings from db
 	if err != nil {
@@ -363,11 +483,30 @@ func GetRecommendedMovies(client *mongo.Client) gin.HandlerFunc {
 		findOptions.SetLimit(recommendedMovieLimitVal)
 
 		//Filter only by favourite genres from user
-		filter := bson.M{"genre.genre_name": bson.M{"$in": favourite_genres}}
+		filter := excludeArchived(bson.M{"genre.genre_name": bson.M{"$in": favourite_genres}})
 
 		var ctx, cancel = context.WithTimeout(c, time.Second*100)
 		defer cancel()
 
+		//Optionally leave out the movies the user already played
+		excludeWatched, err := strconv.ParseBool(c.DefaultQuery("exclude_watched", "false"))
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exclude_watched must be true or false"})
+			return
+		}
+
+		if excludeWatched {
+			watchedIds, err := findWatchedMovieIds(ctx, client, userId)
+
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching watch history"})
+				return
+			}
+
+			filter["imdb_id"] = bson.M{"$nin": watchedIds}
+		}
+
 		// Get collection
 		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
 
@@ -388,6 +527,16 @@ func GetRecommendedMovies(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
+		movies := make([]*models.Movie, len(recommendedMovies))
+		for i := range recommendedMovies {
+			movies[i] = &recommendedMovies[i]
+		}
+
+		if err := personalizeMovies(ctx, c, client, movies...); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user data"})
+			return
+		}
+
 		c.JSON(http.StatusOK, recommendedMovies)
 
 	}
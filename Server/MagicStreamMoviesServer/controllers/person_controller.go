@@ -0,0 +1,362 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Function that adds a person to people collection (Admin only)
+func CreatePerson(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var person models.Person
+
+		if err := c.ShouldBindJSON(&person); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		if err := validate.Struct(person); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		person.ID = bson.ObjectID{}
+		person.PersonID = bson.NewObjectID().Hex()
+		person.CreatedAt = time.Now()
+		person.UpdatedAt = time.Now()
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var peopleCollection *mongo.Collection = database.OpenCollection("people", client)
+
+		result, err := peopleCollection.InsertOne(ctx, person)
+
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Person already exists"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add person"})
+			return
+		}
+
+		person.ID, _ = result.InsertedID.(bson.ObjectID)
+
+		c.JSON(http.StatusCreated, person)
+	}
+}
+
+// Function that partially updates a person (Admin only). A new name is also written on the movies crediting the person
+func UpdatePerson(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		personId := c.Param("person_id")
+
+		var update models.PersonUpdate
+
+		if err := c.ShouldBindJSON(&update); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var peopleCollection *mongo.Collection = database.OpenCollection("people", client)
+
+		var person models.Person
+
+		if err := peopleCollection.FindOne(ctx, bson.M{"person_id": personId}).Decode(&person); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+			return
+		}
+
+		oldName := person.Name
+		set := bson.M{}
+
+		if update.Name != nil {
+			person.Name = *update.Name
+			set["name"] = person.Name
+		}
+
+		if update.Biography != nil {
+			person.Biography = *update.Biography
+			set["biography"] = person.Biography
+		}
+
+		if update.BirthDate != nil {
+			person.BirthDate = update.BirthDate
+			set["birth_date"] = person.BirthDate
+		}
+
+		if update.ProfilePath != nil {
+			person.ProfilePath = *update.ProfilePath
+			set["profile_path"] = person.ProfilePath
+		}
+
+		if update.ImdbID != nil {
+			person.ImdbID = *update.ImdbID
+			set["imdb_id"] = person.ImdbID
+		}
+
+		if len(set) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+			return
+		}
+
+		if err := validate.Struct(person); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		person.UpdatedAt = time.Now()
+		set["updated_at"] = person.UpdatedAt
+
+		err := runInTransaction(ctx, client, func(ctx context.Context) error {
+			if _, err := peopleCollection.UpdateOne(ctx, bson.M{"person_id": personId}, bson.M{"$set": set}); err != nil {
+				return err
+			}
+
+			if person.Name == oldName {
+				return nil
+			}
+
+			//Directors and cast names of crediting movies are copies of the credited names. They are rebuilt from
+			//credits instead of matched by name, other people on the movie may share the old name
+			var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+			cursor, err := movieCollection.Find(ctx, bson.M{"credits.person_id": personId})
+			if err != nil {
+				return err
+			}
+
+			var movies []models.Movie
+			if err := cursor.All(ctx, &movies); err != nil {
+				return err
+			}
+
+			for _, movie := range movies {
+				//The person was already renamed inside this transaction
+				if err := utils.ApplyCredits(ctx, client, &movie); err != nil {
+					return err
+				}
+
+				_, err := movieCollection.UpdateOne(ctx,
+					bson.M{"_id": movie.ID},
+					bson.M{"$set": bson.M{"directors": movie.Directors, "cast": movie.Cast}})
+
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating person", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, person)
+	}
+}
+
+// Function that deletes a person (Admin only). People credited on movies can not be deleted
+func DeletePerson(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		personId := c.Param("person_id")
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		credited, err := movieCollection.CountDocuments(ctx, bson.M{"credits.person_id": personId})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check person credits"})
+			return
+		}
+
+		if credited > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Person is credited on movies", "movies": credited})
+			return
+		}
+
+		var peopleCollection *mongo.Collection = database.OpenCollection("people", client)
+
+		result, err := peopleCollection.DeleteOne(ctx, bson.M{"person_id": personId})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete person"})
+			return
+		}
+
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Person deleted"})
+	}
+}
+
+// Function that returns a person together with the movies they took part in, newest first
+func GetPerson(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		personId := c.Param("person_id")
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var peopleCollection *mongo.Collection = database.OpenCollection("people", client)
+
+		var details models.PersonDetails
+
+		if err := peopleCollection.FindOne(ctx, bson.M{"person_id": personId}).Decode(&details.Person); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+			return
+		}
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		findOptions := options.Find().SetSort(bson.D{{Key: "release_date", Value: -1}, {Key: "_id", Value: -1}})
+
+		cursor, err := movieCollection.Find(ctx, excludeArchived(bson.M{"credits.person_id": personId}), findOptions)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching filmography"})
+			return
+		}
+
+		var movies []models.Movie
+		if err := cursor.All(ctx, &movies); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		//One entry per credit, a person can be director and actor of the same movie
+		details.Filmography = []models.FilmographyEntry{}
+		for _, movie := range movies {
+			for _, credit := range movie.Credits {
+				if credit.PersonID != personId {
+					continue
+				}
+
+				details.Filmography = append(details.Filmography, models.FilmographyEntry{
+					ImdbID:      movie.ImdbID,
+					Title:       movie.Title,
+					PosterPath:  movie.PosterPath,
+					ReleaseDate: movie.ReleaseDate,
+					Role:        credit.Role,
+					Character:   credit.Character,
+					Order:       credit.Order,
+				})
+			}
+		}
+
+		c.JSON(http.StatusOK, details)
+	}
+}
+
+// Function that recommends movies sharing cast and crew with the given movie, the more people in common the higher
+func GetRelatedMovies(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieId := c.Param("imdb_id")
+
+		limit := int64(10)
+		if value := c.Query("limit"); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed < 1 || parsed > 50 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+				return
+			}
+			limit = parsed
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		var movie models.Movie
+
+		if err := movieCollection.FindOne(ctx, excludeArchived(bson.M{"imdb_id": movieId})).Decode(&movie); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		related, err := findMoviesSharingPeople(ctx, movieCollection, movie, limit)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching related movies"})
+			return
+		}
+
+		c.JSON(http.StatusOK, related)
+	}
+}
+
+// Function that returns up to limit movies crediting any person credited on movie, sorted by people in common and ranking
+func findMoviesSharingPeople(ctx context.Context, collection *mongo.Collection, movie models.Movie, limit int64) ([]models.RelatedMovie, error) {
+	related := []models.RelatedMovie{}
+
+	ids := bson.A{}
+	seen := map[string]bool{}
+	for _, credit := range movie.Credits {
+		if !seen[credit.PersonID] {
+			seen[credit.PersonID] = true
+			ids = append(ids, credit.PersonID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return related, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: excludeArchived(bson.M{
+			"credits.person_id": bson.M{"$in": ids},
+			"imdb_id":           bson.M{"$ne": movie.ImdbID},
+		})}},
+		{{Key: "$addFields", Value: bson.M{"shared_people": bson.M{"$size": bson.M{"$setIntersection": bson.A{"$credits.person_id", ids}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "shared_people", Value: -1}, {Key: "ranking.ranking_value", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cursor.All(ctx, &related); err != nil {
+		return nil, err
+	}
+
+	return related, nil
+}
+
+// Function that writes the response when credits of a movie could not be applied
+func respondCreditsError(c *gin.Context, err error) {
+	var unknown utils.UnknownPersonError
+
+	if errors.As(err, &unknown) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": unknown.Error()})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check movie credits"})
+}
@@ -0,0 +1,437 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/sentiment"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var errRankingNotFound = errors.New("ranking not found")
+
+// Movies ranked again by one recompute request when no limit is given, and the most a request can ask for
+const defaultRecomputeBatch = 10
+const maxRecomputeBatch = 50
+
+// Error returned when a change would leave the rankings invalid
+type rankingSetError struct {
+	reason string
+}
+
+func (e rankingSetError) Error() string {
+	return e.reason
+}
+
+// Function that checks that rankings have positive unique values and names and that every ranking excluded from AI
+// is ordered after the rankings the LLM can choose, so sorting movies by ranking value puts unranked movies last
+func validateRankingSet(rankings []models.Ranking) error {
+	values := map[int]bool{}
+	names := map[string]bool{}
+	maxSelectable := 0
+	minExcluded := 0
+
+	for _, ranking := range rankings {
+		if err := validate.Struct(ranking); err != nil {
+			return rankingSetError{err.Error()}
+		}
+
+		if ranking.RankingValue <= 0 {
+			return rankingSetError{fmt.Sprintf("ranking value %d must be positive", ranking.RankingValue)}
+		}
+
+		if values[ranking.RankingValue] {
+			return rankingSetError{fmt.Sprintf("ranking value %d is already used", ranking.RankingValue)}
+		}
+		values[ranking.RankingValue] = true
+
+		if names[ranking.RankingName] {
+			return rankingSetError{fmt.Sprintf("ranking name %s is already used", ranking.RankingName)}
+		}
+		names[ranking.RankingName] = true
+
+		if ranking.ExcludedFromAI {
+			if minExcluded == 0 || ranking.RankingValue < minExcluded {
+				minExcluded = ranking.RankingValue
+			}
+		} else if ranking.RankingValue > maxSelectable {
+			maxSelectable = ranking.RankingValue
+		}
+	}
+
+	if minExcluded != 0 && minExcluded < maxSelectable {
+		return rankingSetError{"rankings excluded from AI must have greater values than the rankings the AI can choose"}
+	}
+
+	return nil
+}
+
+// Function that returns every ranking sorted by value
+func ListRankings(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rankings, err := GetRankings(client, c)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching rankings"})
+			return
+		}
+
+		c.JSON(http.StatusOK, rankings)
+	}
+}
+
+// Function that adds a ranking (Admin only)
+func CreateRanking(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ranking models.Ranking
+
+		if err := c.ShouldBindJSON(&ranking); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		rankings, err := GetRankings(client, c)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching rankings"})
+			return
+		}
+
+		if err := validateRankingSet(append(rankings, ranking)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var rankingsCollection *mongo.Collection = database.OpenCollection("rankings", client)
+
+		_, err = rankingsCollection.InsertOne(ctx, ranking)
+
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Ranking already exists"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add ranking"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, ranking)
+	}
+}
+
+// Function that changes value, name or AI flag of a ranking (Admin only).
+// Value and name are also changed on every movie and review holding the ranking inside a transaction
+func UpdateRanking(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rankingValue, err := strconv.Atoi(c.Param("ranking_value"))
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ranking value"})
+			return
+		}
+
+		var update models.RankingUpdate
+
+		if err := c.ShouldBindJSON(&update); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var updated models.Ranking
+
+		err = runInTransaction(ctx, client, func(ctx context.Context) error {
+			var rankingsCollection *mongo.Collection = database.OpenCollection("rankings", client)
+
+			cursor, err := rankingsCollection.Find(ctx, bson.M{})
+			if err != nil {
+				return err
+			}
+
+			var rankings []models.Ranking
+			if err := cursor.All(ctx, &rankings); err != nil {
+				return err
+			}
+
+			//Apply the update over the stored ranking and validate the resulting set of rankings
+			index := -1
+			for i, ranking := range rankings {
+				if ranking.RankingValue == rankingValue {
+					index = i
+				}
+			}
+
+			if index == -1 {
+				return errRankingNotFound
+			}
+
+			updated = rankings[index]
+			if update.RankingValue != nil {
+				updated.RankingValue = *update.RankingValue
+			}
+			if update.RankingName != nil {
+				updated.RankingName = *update.RankingName
+			}
+			if update.ExcludedFromAI != nil {
+				updated.ExcludedFromAI = *update.ExcludedFromAI
+			}
+			rankings[index] = updated
+
+			if err := validateRankingSet(rankings); err != nil {
+				return err
+			}
+
+			_, err = rankingsCollection.ReplaceOne(ctx, bson.M{"ranking_value": rankingValue}, updated)
+			if err != nil {
+				return err
+			}
+
+			//Keep the copies embedded on movies in sync
+			var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+			_, err = movieCollection.UpdateMany(ctx,
+				bson.M{"ranking.ranking_value": rankingValue},
+				bson.M{"$set": bson.M{
+					"ranking.ranking_value": updated.RankingValue,
+					"ranking.ranking_name":  updated.RankingName,
+				}})
+			if err != nil {
+				return err
+			}
+
+			//Reviews ranked by the classifier hold a copy too
+			var reviewsCollection *mongo.Collection = database.OpenCollection("reviews", client)
+			_, err = reviewsCollection.UpdateMany(ctx,
+				bson.M{"ranking.ranking_value": rankingValue},
+				bson.M{"$set": bson.M{
+					"ranking.ranking_value": updated.RankingValue,
+					"ranking.ranking_name":  updated.RankingName,
+				}})
+
+			return err
+		})
+
+		if err != nil {
+			respondRankingError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// Function that deletes a ranking (Admin only). Movies holding it keep their copy until rankings are recomputed
+func DeleteRanking(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rankingValue, err := strconv.Atoi(c.Param("ranking_value"))
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ranking value"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var rankingsCollection *mongo.Collection = database.OpenCollection("rankings", client)
+
+		result, err := rankingsCollection.DeleteOne(ctx, bson.M{"ranking_value": rankingValue})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete ranking"})
+			return
+		}
+
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ranking not found"})
+			return
+		}
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		affected, err := movieCollection.CountDocuments(ctx, bson.M{"ranking.ranking_value": rankingValue})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count affected movies"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Ranking deleted", "affected_movies": affected})
+	}
+}
+
+// Function that ranks again the movies affected by ranking changes (Admin only): movies whose ranking does not exist
+// anymore and reviewed movies holding a ranking excluded from AI. With all=true every reviewed movie is ranked again.
+// Reviewed movies are ranked by the LLM, the rest get the first ranking excluded from AI (for example Not_Ranked).
+// Every LLM answer can take several round trips so movies are processed in batches of limit movies (oldest first).
+// The response holds next_cursor while movies are left, call again with it to process the following batch
+func RecomputeRankings(client *mongo.Client, classifier sentiment.Classifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		all, err := strconv.ParseBool(c.DefaultQuery("all", "false"))
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "all must be true or false"})
+			return
+		}
+
+		var query models.PageQuery
+
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+			return
+		}
+
+		if err := validate.Struct(query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		if query.Limit == 0 {
+			query.Limit = defaultRecomputeBatch
+		}
+
+		if query.Limit > maxRecomputeBatch {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be at most %d", maxRecomputeBatch)})
+			return
+		}
+
+		keysetQuery, err := utils.NewKeysetQuery("_id", true, query.Limit, query.Cursor)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+
+		rankings, err := GetRankings(client, c)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching rankings"})
+			return
+		}
+
+		//Rankings are sorted by value so this is the first excluded ranking
+		var defaultRanking *models.Ranking
+		validPairs := bson.A{}
+		excludedValues := bson.A{}
+
+		for _, ranking := range rankings {
+			validPairs = append(validPairs, bson.M{"ranking.ranking_value": ranking.RankingValue, "ranking.ranking_name": ranking.RankingName})
+
+			if ranking.ExcludedFromAI {
+				excludedValues = append(excludedValues, ranking.RankingValue)
+				if defaultRanking == nil {
+					defaultRanking = &models.Ranking{RankingValue: ranking.RankingValue, RankingName: ranking.RankingName}
+				}
+			}
+		}
+
+		reviewed := bson.M{"admin_review": bson.M{"$nin": bson.A{"", nil}}}
+
+		var filter bson.M
+		if all {
+			filter = reviewed
+		} else {
+			affected := bson.A{bson.M{"$and": bson.A{reviewed, bson.M{"ranking.ranking_value": bson.M{"$in": excludedValues}}}}}
+			if len(validPairs) > 0 {
+				affected = append(affected, bson.M{"$nor": validPairs})
+			}
+			filter = bson.M{"$or": affected}
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		//Movies fixed by earlier batches no longer match the filter, the cursor keeps moving over the ones that failed
+		batch, err := utils.FindPage[models.Movie](ctx, movieCollection, filter, keysetQuery)
+
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor does not match sort option"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching affected movies"})
+			return
+		}
+
+		type failure struct {
+			ImdbID string `json:"imdb_id"`
+			Error  string `json:"error"`
+		}
+
+		recomputed := 0
+		failed := []failure{}
+
+		for _, movie := range batch.Items {
+			var ranking models.Ranking
+
+			if movie.AdminReview != "" {
+				classification, err := classifier.Classify(ctx, movie.AdminReview, rankings)
+
+				if err != nil {
+					failed = append(failed, failure{movie.ImdbID, err.Error()})
+					continue
+				}
+
+				ranking = classification.Ranking
+			} else {
+				if defaultRanking == nil {
+					failed = append(failed, failure{movie.ImdbID, "no ranking excluded from AI to assign to movies without review"})
+					continue
+				}
+
+				ranking = *defaultRanking
+			}
+
+			_, err := movieCollection.UpdateOne(ctx, bson.M{"_id": movie.ID}, bson.M{"$set": bson.M{"ranking": ranking}})
+
+			if err != nil {
+				failed = append(failed, failure{movie.ImdbID, err.Error()})
+				continue
+			}
+
+			recomputed++
+		}
+
+		//affected counts every movie still matching when the batch started, including failures left behind by earlier batches
+		c.JSON(http.StatusOK, gin.H{
+			"affected":    batch.Total,
+			"processed":   len(batch.Items),
+			"recomputed":  recomputed,
+			"failed":      failed,
+			"next_cursor": batch.NextCursor,
+		})
+	}
+}
+
+// Function that writes the response of a failed ranking change
+func respondRankingError(c *gin.Context, err error) {
+	var setErr rankingSetError
+
+	switch {
+	case errors.Is(err, errRankingNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ranking not found"})
+	case errors.As(err, &setErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": setErr.Error()})
+	case mongo.IsDuplicateKeyError(err):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ranking already exists"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating ranking", "details": err.Error()})
+	}
+}
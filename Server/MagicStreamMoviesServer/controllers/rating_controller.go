@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var errMovieNotFound = errors.New("movie not found")
+var errRatingNotFound = errors.New("rating not found")
+
+// Function that sets MyRating on movies for the authenticated user. Anonymous requests leave every rating empty
+func markRatings(ctx context.Context, c *gin.Context, client *mongo.Client, movies ...*models.Movie) error {
+	userId, err := utils.GetUserIdFromContext(c)
+	if err != nil || len(movies) == 0 {
+		return nil
+	}
+
+	ids := bson.A{}
+	for _, movie := range movies {
+		ids = append(ids, movie.ImdbID)
+	}
+
+	var ratingsCollection *mongo.Collection = database.OpenCollection("ratings", client)
+
+	cursor, err := ratingsCollection.Find(ctx, bson.M{"user_id": userId, "imdb_id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+
+	var ratings []models.Rating
+	if err := cursor.All(ctx, &ratings); err != nil {
+		return err
+	}
+
+	stars := map[string]int{}
+	for _, rating := range ratings {
+		stars[rating.ImdbID] = rating.Stars
+	}
+
+	for _, movie := range movies {
+		movie.MyRating = stars[movie.ImdbID]
+	}
+
+	return nil
+}
+
+// Function that adds starsDelta to the rating sum and countDelta to the rating count of a movie and recomputes its
+// average in the same atomic update
+func applyRatingChange(ctx context.Context, client *mongo.Client, movieId string, starsDelta, countDelta int) (models.Movie, error) {
+	var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"rating_sum":   bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating_sum", 0}}, starsDelta}},
+			"rating_count": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating_count", 0}}, countDelta}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"rating_average": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$rating_count", 0}},
+				bson.M{"$round": bson.A{bson.M{"$divide": bson.A{"$rating_sum", "$rating_count"}}, 2}},
+				0,
+			}},
+		}}},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var movie models.Movie
+	err := movieCollection.FindOneAndUpdate(ctx, bson.M{"imdb_id": movieId}, update, opts).Decode(&movie)
+
+	if err == mongo.ErrNoDocuments {
+		return movie, errMovieNotFound
+	}
+
+	return movie, err
+}
+
+// Function that creates or changes the stars the current user gives to a movie
+func RateMovie(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieId := c.Param("imdb_id")
+
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		var input models.RatingInput
+
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		if err := validate.Struct(input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		count, err := movieCollection.CountDocuments(ctx, excludeArchived(bson.M{"imdb_id": movieId}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check movie"})
+			return
+		}
+
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		var ratingsCollection *mongo.Collection = database.OpenCollection("ratings", client)
+
+		var movie models.Movie
+
+		//Rating and movie aggregates change together
+		err = runInTransaction(ctx, client, func(ctx context.Context) error {
+			now := time.Now()
+
+			update := bson.M{
+				"$set":         bson.M{"stars": input.Stars, "updated_at": now},
+				"$setOnInsert": bson.M{"user_id": userId, "imdb_id": movieId, "created_at": now},
+			}
+
+			//The previous rating tells how much the aggregates change
+			opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+			var previous models.Rating
+			err := ratingsCollection.FindOneAndUpdate(ctx, bson.M{"user_id": userId, "imdb_id": movieId}, update, opts).Decode(&previous)
+
+			starsDelta, countDelta := input.Stars, 1
+
+			if err == nil {
+				starsDelta, countDelta = input.Stars-previous.Stars, 0
+			} else if err != mongo.ErrNoDocuments {
+				return err
+			}
+
+			movie, err = applyRatingChange(ctx, client, movieId, starsDelta, countDelta)
+			return err
+		})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save rating", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.RatingSummary{
+			ImdbID:        movieId,
+			RatingCount:   movie.RatingCount,
+			RatingAverage: movie.RatingAverage,
+			MyRating:      input.Stars,
+		})
+	}
+}
+
+// Function that deletes the rating the current user gave to a movie
+func DeleteRating(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieId := c.Param("imdb_id")
+
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var ratingsCollection *mongo.Collection = database.OpenCollection("ratings", client)
+
+		var movie models.Movie
+
+		err = runInTransaction(ctx, client, func(ctx context.Context) error {
+			var previous models.Rating
+
+			err := ratingsCollection.FindOneAndDelete(ctx, bson.M{"user_id": userId, "imdb_id": movieId}).Decode(&previous)
+
+			if err == mongo.ErrNoDocuments {
+				return errRatingNotFound
+			}
+
+			if err != nil {
+				return err
+			}
+
+			movie, err = applyRatingChange(ctx, client, movieId, -previous.Stars, -1)
+			return err
+		})
+
+		if errors.Is(err, errRatingNotFound) || errors.Is(err, errMovieNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rating not found"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete rating", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.RatingSummary{
+			ImdbID:        movieId,
+			RatingCount:   movie.RatingCount,
+			RatingAverage: movie.RatingAverage,
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/sentiment"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Statuses a review can be moved from to reach each moderation status
+var reviewTransitions = map[string]bson.A{
+	models.ReviewApproved: {models.ReviewPending, models.ReviewRejected, models.ReviewHidden},
+	models.ReviewRejected: {models.ReviewPending},
+	models.ReviewHidden:   {models.ReviewApproved},
+}
+
+// Sort options of the reviews of a movie: field the listing is sorted by, direction and, for computed fields, the
+// expression computing the field. Reviews without ranking sort last on both ranking orders
+var reviewSortOptions = map[string]struct {
+	Field     string
+	Ascending bool
+	Value     any
+}{
+	"":             {"_id", false, nil},
+	"newest":       {"_id", false, nil},
+	"helpful":      {"helpful_sort", false, bson.M{"$ifNull": bson.A{"$helpful_score", 0}}},
+	"ranking_high": {"ranking_high_sort", true, bson.M{"$ifNull": bson.A{"$ranking.ranking_value", math.MaxInt32}}}, //Best rankings have the lowest values
+	"ranking_low":  {"ranking_low_sort", false, bson.M{"$ifNull": bson.A{"$ranking.ranking_value", 0}}},
+}
+
+// Function that posts a review of the current user on a movie. The review is ranked by the classifier and waits
+// on the moderation queue until an admin approves it
+func CreateReview(client *mongo.Client, classifier sentiment.Classifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieId := c.Param("imdb_id")
+
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		var input models.ReviewInput
+
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		input.Text = strings.TrimSpace(input.Text)
+
+		if err := validate.Struct(input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		count, err := movieCollection.CountDocuments(ctx, excludeArchived(bson.M{"imdb_id": movieId}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check movie"})
+			return
+		}
+
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		rankings, err := GetRankings(client, c)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching rankings"})
+			return
+		}
+
+		review := models.Review{
+			ImdbID:    movieId,
+			UserID:    userId,
+			Text:      input.Text,
+			Status:    models.ReviewPending,
+			CreatedAt: time.Now(),
+		}
+
+		classifyReview(ctx, classifier, &review, rankings)
+
+		var reviewsCollection *mongo.Collection = database.OpenCollection("reviews", client)
+
+		result, err := reviewsCollection.InsertOne(ctx, review)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+			return
+		}
+
+		review.ID, _ = result.InsertedID.(bson.ObjectID)
+
+		c.JSON(http.StatusCreated, review)
+	}
+}
+
+// Function that ranks a review with the classifier. A failed classification does not lose the review, it is kept
+// without ranking and moderators see why
+func classifyReview(ctx context.Context, classifier sentiment.Classifier, review *models.Review, rankings []models.Ranking) {
+	classification, err := classifier.Classify(ctx, review.Text, rankings)
+
+	if err != nil {
+		log.Println("Warning: unable to classify review:", err)
+		review.ClassificationError = err.Error()
+		return
+	}
+
+	review.Ranking = &classification.Ranking
+	review.RankingConfidence = classification.Confidence
+	review.RankingRationale = classification.Rationale
+}
+
+// Function that returns one page of the approved reviews of a movie, newest first unless sorted by helpful votes or ranking
+func GetMovieReviews(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query models.ReviewListQuery
+
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+			return
+		}
+
+		if err := validate.Struct(query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		sort := reviewSortOptions[query.Sort]
+
+		keysetQuery, err := utils.NewKeysetQuery(sort.Field, sort.Ascending, query.Limit, query.Cursor)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var reviewsCollection *mongo.Collection = database.OpenCollection("reviews", client)
+
+		stages := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"imdb_id": c.Param("imdb_id"), "status": models.ReviewApproved}}},
+		}
+
+		if sort.Value != nil {
+			stages = append(stages, bson.D{{Key: "$set", Value: bson.M{sort.Field: sort.Value}}})
+		}
+
+		page, err := utils.AggregatePage[models.Review](ctx, reviewsCollection, stages, keysetQuery)
+
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor does not match sort option"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+			return
+		}
+
+		reviews := make([]*models.Review, len(page.Items))
+		for i := range page.Items {
+			reviews[i] = &page.Items[i]
+		}
+
+		if err := markReviewVotes(ctx, c, client, reviews...); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user data"})
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// Function that returns one page of the moderation queue, oldest first (Admin only). Pending reviews unless status is given
+func GetReviewQueue(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query models.ReviewQueueQuery
+
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+			return
+		}
+
+		if err := validate.Struct(query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		if query.Status == "" {
+			query.Status = models.ReviewPending
+		}
+
+		keysetQuery, err := utils.NewKeysetQuery("_id", true, query.Limit, query.Cursor)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var reviewsCollection *mongo.Collection = database.OpenCollection("reviews", client)
+
+		page, err := utils.FindPage[models.Review](ctx, reviewsCollection, bson.M{"status": query.Status}, keysetQuery)
+
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor does not match sort option"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// Function that publishes a pending, rejected or hidden review (Admin only)
+func ApproveReview(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		moderateReview(c, client, models.ReviewApproved)
+	}
+}
+
+// Function that rejects a pending review (Admin only)
+func RejectReview(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		moderateReview(c, client, models.ReviewRejected)
+	}
+}
+
+// Function that takes an approved review down from the movie listing (Admin only)
+func HideReview(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		moderateReview(c, client, models.ReviewHidden)
+	}
+}
+
+// Function that moves the review given on the route to status when its current status allows it
+func moderateReview(c *gin.Context, client *mongo.Client, status string) {
+	reviewId, err := bson.ObjectIDFromHex(c.Param("review_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review id"})
+		return
+	}
+
+	adminId, err := utils.GetUserIdFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c, 100*time.Second)
+	defer cancel()
+
+	var reviewsCollection *mongo.Collection = database.OpenCollection("reviews", client)
+
+	filter := bson.M{"_id": reviewId, "status": bson.M{"$in": reviewTransitions[status]}}
+	update := bson.M{"$set": bson.M{"status": status, "moderated_by": adminId, "moderated_at": time.Now()}}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var review models.Review
+	err = reviewsCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&review)
+
+	if err == nil {
+		c.JSON(http.StatusOK, review)
+		return
+	}
+
+	if err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating review"})
+		return
+	}
+
+	//Nothing matched, either the review does not exist or its status does not allow the change
+	err = reviewsCollection.FindOne(ctx, bson.M{"_id": reviewId}).Decode(&review)
+
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching review"})
+		return
+	}
+
+	c.JSON(http.StatusConflict, gin.H{"error": "Review can not be " + status + " while it is " + review.Status})
+}
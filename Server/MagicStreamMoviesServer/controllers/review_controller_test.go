@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/sentiment"
+)
+
+var testRankings = []models.Ranking{
+	{RankingValue: 1, RankingName: "Excellent"},
+	{RankingValue: 2, RankingName: "Good"},
+	{RankingValue: 3, RankingName: "Okay"},
+	{RankingValue: 4, RankingName: "Bad"},
+	{RankingValue: 5, RankingName: "Terrible"},
+	{RankingValue: 999, RankingName: "Not_Ranked", ExcludedFromAI: true},
+}
+
+func TestClassifyReview(t *testing.T) {
+	classifier := &sentiment.Fake{RankingName: "good"}
+	review := models.Review{Text: "Loved the soundtrack", Status: models.ReviewPending}
+
+	classifyReview(context.Background(), classifier, &review, testRankings)
+
+	if review.Ranking == nil {
+		t.Fatalf("review has no ranking, classification error %q", review.ClassificationError)
+	}
+	if review.Ranking.RankingName != "Good" || review.Ranking.RankingValue != 2 {
+		t.Errorf("ranking = %+v, want Good (2)", *review.Ranking)
+	}
+	if review.RankingConfidence != 1 || review.RankingRationale == "" {
+		t.Errorf("confidence = %v, rationale = %q, want the classifier answer", review.RankingConfidence, review.RankingRationale)
+	}
+	if review.ClassificationError != "" {
+		t.Errorf("classification error = %q, want none", review.ClassificationError)
+	}
+	if review.Status != models.ReviewPending {
+		t.Errorf("status = %q, classification must not moderate the review", review.Status)
+	}
+
+	if reviews := classifier.Reviews(); len(reviews) != 1 || reviews[0] != "Loved the soundtrack" {
+		t.Errorf("classified reviews = %q, want the review text", reviews)
+	}
+}
+
+func TestClassifyReviewFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		classifier *sentiment.Fake
+		want       string
+	}{
+		{"classifier error", &sentiment.Fake{Err: errors.New("model unavailable")}, "model unavailable"},
+		{"unknown ranking", &sentiment.Fake{RankingName: "Masterpiece"}, sentiment.ErrUnknownRanking.Error()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			review := models.Review{Text: "Fell asleep halfway", Status: models.ReviewPending}
+
+			classifyReview(context.Background(), test.classifier, &review, testRankings)
+
+			if review.Ranking != nil {
+				t.Errorf("ranking = %+v, want none", *review.Ranking)
+			}
+			if review.RankingConfidence != 0 || review.RankingRationale != "" {
+				t.Errorf("confidence = %v, rationale = %q, want none", review.RankingConfidence, review.RankingRationale)
+			}
+			if !strings.Contains(review.ClassificationError, test.want) {
+				t.Errorf("classification error = %q, want it to contain %q", review.ClassificationError, test.want)
+			}
+			if review.Status != models.ReviewPending {
+				t.Errorf("status = %q, a failed classification keeps the review pending", review.Status)
+			}
+		})
+	}
+}
+
+func TestReviewTransitions(t *testing.T) {
+	statuses := []string{models.ReviewPending, models.ReviewApproved, models.ReviewRejected, models.ReviewHidden}
+
+	allowed := map[string]bool{
+		models.ReviewPending + ">" + models.ReviewApproved:  true,
+		models.ReviewRejected + ">" + models.ReviewApproved: true,
+		models.ReviewHidden + ">" + models.ReviewApproved:   true,
+		models.ReviewPending + ">" + models.ReviewRejected:  true,
+		models.ReviewApproved + ">" + models.ReviewHidden:   true,
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			name := from + ">" + to
+
+			got := false
+			for _, status := range reviewTransitions[to] {
+				if status == from {
+					got = true
+				}
+			}
+
+			if got != allowed[name] {
+				t.Errorf("moving a review from %s to %s allowed = %v, want %v", from, to, got, allowed[name])
+			}
+		}
+	}
+
+	//Reviews are never moved back to the moderation queue
+	if _, ok := reviewTransitions[models.ReviewPending]; ok {
+		t.Errorf("reviews can be moved back to %s", models.ReviewPending)
+	}
+}
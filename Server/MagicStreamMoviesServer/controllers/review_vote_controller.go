@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var errVoteNotFound = errors.New("vote not found")
+
+// Function that sets MyVote on reviews for the authenticated user
+func markReviewVotes(ctx context.Context, c *gin.Context, client *mongo.Client, reviews ...*models.Review) error {
+	userId, err := utils.GetUserIdFromContext(c)
+	if err != nil || len(reviews) == 0 {
+		return nil
+	}
+
+	ids := bson.A{}
+	for _, review := range reviews {
+		ids = append(ids, review.ID)
+	}
+
+	var votesCollection *mongo.Collection = database.OpenCollection("review_votes", client)
+
+	cursor, err := votesCollection.Find(ctx, bson.M{"user_id": userId, "review_id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+
+	var votes []models.ReviewVote
+	if err := cursor.All(ctx, &votes); err != nil {
+		return err
+	}
+
+	byReview := map[bson.ObjectID]string{}
+	for _, vote := range votes {
+		byReview[vote.ReviewID] = vote.Vote
+	}
+
+	for _, review := range reviews {
+		review.MyVote = byReview[review.ID]
+	}
+
+	return nil
+}
+
+// Function that returns 1 when vote is v, used to turn a change of vote into counter increments
+func countVote(vote, v string) int {
+	if vote == v {
+		return 1
+	}
+	return 0
+}
+
+// Function that moves the helpful counters of a review from the previous vote of a user to the new one (empty when
+// there is none) and returns the updated review
+func applyVoteChange(ctx context.Context, client *mongo.Client, reviewId bson.ObjectID, previous, vote string) (models.Review, error) {
+	var reviewsCollection *mongo.Collection = database.OpenCollection("reviews", client)
+
+	up := countVote(vote, models.VoteUp) - countVote(previous, models.VoteUp)
+	down := countVote(vote, models.VoteDown) - countVote(previous, models.VoteDown)
+
+	update := bson.M{"$inc": bson.M{"helpful_up": up, "helpful_down": down, "helpful_score": up - down}}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var review models.Review
+	err := reviewsCollection.FindOneAndUpdate(ctx, bson.M{"_id": reviewId}, update, opts).Decode(&review)
+
+	return review, err
+}
+
+// Function that records whether the current user found an approved review helpful (up) or not (down). Voting again
+// replaces the previous vote
+func VoteReview(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reviewId, err := bson.ObjectIDFromHex(c.Param("review_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review id"})
+			return
+		}
+
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		var input models.ReviewVoteInput
+
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		if err := validate.Struct(input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var reviewsCollection *mongo.Collection = database.OpenCollection("reviews", client)
+
+		var review models.Review
+		err = reviewsCollection.FindOne(ctx, bson.M{"_id": reviewId, "status": models.ReviewApproved}).Decode(&review)
+
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching review"})
+			return
+		}
+
+		if review.UserID == userId {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Users can not vote on their own reviews"})
+			return
+		}
+
+		var votesCollection *mongo.Collection = database.OpenCollection("review_votes", client)
+
+		//Vote and review counters change together
+		err = runInTransaction(ctx, client, func(ctx context.Context) error {
+			now := time.Now()
+
+			update := bson.M{
+				"$set":         bson.M{"vote": input.Vote, "updated_at": now},
+				"$setOnInsert": bson.M{"review_id": reviewId, "user_id": userId, "created_at": now},
+			}
+
+			opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+			var previous models.ReviewVote
+			err := votesCollection.FindOneAndUpdate(ctx, bson.M{"review_id": reviewId, "user_id": userId}, update, opts).Decode(&previous)
+
+			if err != nil && err != mongo.ErrNoDocuments {
+				return err
+			}
+
+			review, err = applyVoteChange(ctx, client, reviewId, previous.Vote, input.Vote)
+			return err
+		})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save vote", "details": err.Error()})
+			return
+		}
+
+		review.MyVote = input.Vote
+
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+// Function that removes the helpful vote the current user gave to a review
+func DeleteReviewVote(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reviewId, err := bson.ObjectIDFromHex(c.Param("review_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review id"})
+			return
+		}
+
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var votesCollection *mongo.Collection = database.OpenCollection("review_votes", client)
+
+		var review models.Review
+
+		err = runInTransaction(ctx, client, func(ctx context.Context) error {
+			var previous models.ReviewVote
+
+			err := votesCollection.FindOneAndDelete(ctx, bson.M{"review_id": reviewId, "user_id": userId}).Decode(&previous)
+
+			if err == mongo.ErrNoDocuments {
+				return errVoteNotFound
+			}
+
+			if err != nil {
+				return err
+			}
+
+			review, err = applyVoteChange(ctx, client, reviewId, previous.Vote, "")
+			return err
+		})
+
+		if errors.Is(err, errVoteNotFound) || err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Vote not found"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete vote", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, review)
+	}
+}
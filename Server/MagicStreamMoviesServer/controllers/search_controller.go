@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Sort fields of both search modes. They are different so a cursor can only be used with the mode that created it
+const textScoreField = "score"
+const matchScoreField = "match_score"
+
+// Minimum share of the search trigrams a title must contain to be returned by the typo tolerant fallback
+const minMatchScore = 0.5
+
+// Function that searches movies by title, admin review and genre names ordered by relevance.
+// When the text index finds nothing the search falls back to a trigram match on the title so typos still return results
+func SearchMovies(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query models.MovieSearchQuery
+
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+			return
+		}
+
+		if err := validate.Struct(query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		var cursorField string
+		if query.Cursor != "" {
+			cursor, err := utils.DecodeCursor(query.Cursor)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+				return
+			}
+			cursorField = cursor.Field
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		var page models.Page[models.MovieSearchResult]
+		var err error
+
+		//Full text search unless the cursor belongs to the fallback
+		if cursorField != matchScoreField {
+			page, err = searchPage(ctx, movieCollection, textSearchStages(query), textScoreField, query)
+		}
+
+		//Typo tolerant fallback when the text index found nothing at all
+		if err == nil && (cursorField == matchScoreField || (cursorField == "" && page.Total == 0)) {
+			page, err = searchPage(ctx, movieCollection, trigramSearchStages(query), matchScoreField, query)
+		}
+
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search movies", "details": err.Error()})
+			return
+		}
+
+		movies := make([]*models.Movie, len(page.Items))
+		for i := range page.Items {
+			movies[i] = &page.Items[i].Movie
+		}
+
+		if err := personalizeMovies(ctx, c, client, movies...); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user data"})
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// Function that returns one page of search results sorted by descending score
+func searchPage(ctx context.Context, collection *mongo.Collection, stages mongo.Pipeline, field string, query models.MovieSearchQuery) (models.Page[models.MovieSearchResult], error) {
+	keysetQuery, err := utils.NewKeysetQuery(field, false, query.Limit, query.Cursor)
+
+	if err != nil {
+		return models.Page[models.MovieSearchResult]{}, err
+	}
+
+	return utils.AggregatePage[models.MovieSearchResult](ctx, collection, stages, keysetQuery)
+}
+
+// Function that returns the stages matching movies through the text index and exposing the relevance score
+func textSearchStages(query models.MovieSearchQuery) mongo.Pipeline {
+	filter := buildMovieFilter(query.MovieFilters)
+	filter["$text"] = bson.M{"$search": query.Q}
+
+	return mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$addFields", Value: bson.M{textScoreField: bson.M{"$meta": "textScore"}}}},
+	}
+}
+
+// Function that returns the stages scoring every movie title by the share of search trigrams it contains
+func trigramSearchStages(query models.MovieSearchQuery) mongo.Pipeline {
+	grams := trigrams(query.Q)
+
+	if len(grams) == 0 {
+		return mongo.Pipeline{{{Key: "$match", Value: bson.M{"_id": bson.M{"$exists": false}}}}}
+	}
+
+	title := bson.M{"$toLower": "$title"}
+
+	//1 for every trigram found in the lowercase title
+	var hits bson.A
+	for _, gram := range grams {
+		hits = append(hits, bson.M{"$cond": bson.A{
+			bson.M{"$gte": bson.A{bson.M{"$indexOfCP": bson.A{title, gram}}, 0}}, 1, 0,
+		}})
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$match", Value: buildMovieFilter(query.MovieFilters)}},
+		{{Key: "$addFields", Value: bson.M{matchScoreField: bson.M{"$divide": bson.A{bson.M{"$add": hits}, len(grams)}}}}},
+		{{Key: "$match", Value: bson.M{matchScoreField: bson.M{"$gte": minMatchScore}}}},
+		{{Key: "$addFields", Value: bson.M{textScoreField: "$" + matchScoreField}}},
+	}
+}
+
+// Function that splits the search terms into unique lowercase trigrams. Words shorter than 3 characters are kept whole
+func trigrams(q string) []string {
+	seen := map[string]bool{}
+	var grams []string
+
+	for _, word := range strings.Fields(strings.ToLower(q)) {
+		runes := []rune(word)
+
+		if len(runes) < 3 {
+			if !seen[word] {
+				seen[word] = true
+				grams = append(grams, word)
+			}
+			continue
+		}
+
+		for i := 0; i+3 <= len(runes); i++ {
+			gram := string(runes[i : i+3])
+			if !seen[gram] {
+				seen[gram] = true
+				grams = append(grams, gram)
+			}
+		}
+	}
+
+	return grams
+}
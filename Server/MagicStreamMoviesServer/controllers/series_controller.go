@@ -0,0 +1,366 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Function that returns the series given on the route, writing a 404 response when it does not exist
+func findSeries(ctx context.Context, c *gin.Context, client *mongo.Client) (models.Movie, bool) {
+	var series models.Movie
+
+	var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+	filter := excludeArchived(bson.M{"imdb_id": c.Param("imdb_id"), "type": models.ContentTypeSeries})
+
+	if err := movieCollection.FindOne(ctx, filter).Decode(&series); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return series, false
+	}
+
+	return series, true
+}
+
+// Function that reads a positive number route parameter, writing a 400 response when it is not valid
+func numberParam(c *gin.Context, name string) (int, bool) {
+	number, err := strconv.Atoi(c.Param(name))
+
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return 0, false
+	}
+
+	return number, true
+}
+
+// Function that returns a series with its seasons and the amount of episodes of each season
+func GetSeasons(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		series, ok := findSeries(ctx, c, client)
+		if !ok {
+			return
+		}
+
+		var seasonsCollection *mongo.Collection = database.OpenCollection("seasons", client)
+
+		cursor, err := seasonsCollection.Find(ctx, bson.M{"series_id": series.ImdbID}, options.Find().SetSort(bson.D{{Key: "season_number", Value: 1}}))
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching seasons"})
+			return
+		}
+
+		seasons := []models.Season{}
+		if err := cursor.All(ctx, &seasons); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		//Count episodes of every season in one query
+		var episodesCollection *mongo.Collection = database.OpenCollection("episodes", client)
+
+		countCursor, err := episodesCollection.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"series_id": series.ImdbID}}},
+			{{Key: "$group", Value: bson.M{"_id": "$season_number", "count": bson.M{"$sum": 1}}}},
+		})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting episodes"})
+			return
+		}
+
+		var counts []struct {
+			SeasonNumber int `bson:"_id"`
+			Count        int `bson:"count"`
+		}
+		if err := countCursor.All(ctx, &counts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		episodeCounts := map[int]int{}
+		for _, count := range counts {
+			episodeCounts[count.SeasonNumber] = count.Count
+		}
+
+		for i := range seasons {
+			seasons[i].EpisodeCount = episodeCounts[seasons[i].SeasonNumber]
+		}
+
+		c.JSON(http.StatusOK, models.SeriesSeasons{Series: series, Seasons: seasons})
+	}
+}
+
+// Function that returns the episodes of a season in order
+func GetSeasonEpisodes(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		seasonNumber, ok := numberParam(c, "season")
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		series, ok := findSeries(ctx, c, client)
+		if !ok {
+			return
+		}
+
+		var episodesCollection *mongo.Collection = database.OpenCollection("episodes", client)
+
+		cursor, err := episodesCollection.Find(ctx,
+			bson.M{"series_id": series.ImdbID, "season_number": seasonNumber},
+			options.Find().SetSort(bson.D{{Key: "episode_number", Value: 1}}))
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching episodes"})
+			return
+		}
+
+		episodes := []models.Episode{}
+		if err := cursor.All(ctx, &episodes); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, episodes)
+	}
+}
+
+// Function that returns a single episode given series, season and episode number
+func GetEpisode(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		seasonNumber, ok := numberParam(c, "season")
+		if !ok {
+			return
+		}
+
+		episodeNumber, ok := numberParam(c, "episode")
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		series, ok := findSeries(ctx, c, client)
+		if !ok {
+			return
+		}
+
+		var episodesCollection *mongo.Collection = database.OpenCollection("episodes", client)
+
+		var episode models.Episode
+
+		err := episodesCollection.FindOne(ctx, bson.M{
+			"series_id":      series.ImdbID,
+			"season_number":  seasonNumber,
+			"episode_number": episodeNumber,
+		}).Decode(&episode)
+
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, episode)
+	}
+}
+
+// Function that returns the episode watched after the given one: the following episode of the season
+// or the first episode of the following season. Returns 404 after the last episode of the series
+func GetNextEpisode(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		seasonNumber, ok := numberParam(c, "season")
+		if !ok {
+			return
+		}
+
+		episodeNumber, ok := numberParam(c, "episode")
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		series, ok := findSeries(ctx, c, client)
+		if !ok {
+			return
+		}
+
+		var episodesCollection *mongo.Collection = database.OpenCollection("episodes", client)
+
+		filter := bson.M{
+			"series_id": series.ImdbID,
+			"$or": bson.A{
+				bson.M{"season_number": seasonNumber, "episode_number": bson.M{"$gt": episodeNumber}},
+				bson.M{"season_number": bson.M{"$gt": seasonNumber}},
+			},
+		}
+		findOptions := options.FindOne().SetSort(bson.D{{Key: "season_number", Value: 1}, {Key: "episode_number", Value: 1}})
+
+		var episode models.Episode
+
+		err := episodesCollection.FindOne(ctx, filter, findOptions).Decode(&episode)
+
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No next episode"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching next episode"})
+			return
+		}
+
+		c.JSON(http.StatusOK, episode)
+	}
+}
+
+// Function that creates or replaces a season of a series (Admin only)
+func UpsertSeason(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var season models.Season
+
+		if err := c.ShouldBindJSON(&season); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		if err := validate.Struct(season); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		series, ok := findSeries(ctx, c, client)
+		if !ok {
+			return
+		}
+
+		season.ID = bson.ObjectID{}
+		season.SeriesID = series.ImdbID
+
+		var seasonsCollection *mongo.Collection = database.OpenCollection("seasons", client)
+
+		_, err := seasonsCollection.ReplaceOne(ctx,
+			bson.M{"series_id": season.SeriesID, "season_number": season.SeasonNumber},
+			season,
+			options.Replace().SetUpsert(true))
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save season"})
+			return
+		}
+
+		c.JSON(http.StatusOK, season)
+	}
+}
+
+// Function that creates or replaces an episode of a series (Admin only). The season is created when missing
+func UpsertEpisode(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var episode models.Episode
+
+		if err := c.ShouldBindJSON(&episode); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		if err := validate.Struct(episode); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		series, ok := findSeries(ctx, c, client)
+		if !ok {
+			return
+		}
+
+		episode.ID = bson.ObjectID{}
+		episode.SeriesID = series.ImdbID
+
+		var seasonsCollection *mongo.Collection = database.OpenCollection("seasons", client)
+
+		_, err := seasonsCollection.UpdateOne(ctx,
+			bson.M{"series_id": episode.SeriesID, "season_number": episode.SeasonNumber},
+			bson.M{"$setOnInsert": bson.M{"series_id": episode.SeriesID, "season_number": episode.SeasonNumber}},
+			options.UpdateOne().SetUpsert(true))
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save season"})
+			return
+		}
+
+		var episodesCollection *mongo.Collection = database.OpenCollection("episodes", client)
+
+		_, err = episodesCollection.ReplaceOne(ctx,
+			bson.M{"series_id": episode.SeriesID, "season_number": episode.SeasonNumber, "episode_number": episode.EpisodeNumber},
+			episode,
+			options.Replace().SetUpsert(true))
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save episode"})
+			return
+		}
+
+		c.JSON(http.StatusOK, episode)
+	}
+}
+
+// Function that deletes an episode of a series (Admin only)
+func DeleteEpisode(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		seasonNumber, ok := numberParam(c, "season")
+		if !ok {
+			return
+		}
+
+		episodeNumber, ok := numberParam(c, "episode")
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var episodesCollection *mongo.Collection = database.OpenCollection("episodes", client)
+
+		result, err := episodesCollection.DeleteOne(ctx, bson.M{
+			"series_id":      c.Param("imdb_id"),
+			"season_number":  seasonNumber,
+			"episode_number": episodeNumber,
+		})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete episode"})
+			return
+		}
+
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Episode deleted"})
+	}
+}
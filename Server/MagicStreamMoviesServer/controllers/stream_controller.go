@@ -0,0 +1,453 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/media"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/storage"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Largest video accepted by an upload
+const maxVideoSize = 20 << 30
+
+// Content type of every accepted video file extension
+var videoContentTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/x-m4v",
+	".webm": "video/webm",
+	".mov":  "video/quicktime",
+	".mkv":  "video/x-matroska",
+}
+
+// Function that uploads the video of a movie (multipart field "file") to the blob store, replacing the previous one (Admin only)
+func UploadMovieVideo(client *mongo.Client, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieId := c.Param("imdb_id")
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxVideoSize)
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A video file is required", "details": err.Error()})
+			return
+		}
+
+		extension := strings.ToLower(path.Ext(fileHeader.Filename))
+		contentType, ok := videoContentTypes[extension]
+
+		if !ok {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported video, upload an mp4, m4v, webm, mov or mkv file"})
+			return
+		}
+
+		//Video uploads can take long, timeouts only bound the db queries before and after copying the file
+		checkCtx, cancelCheck := context.WithTimeout(c, 100*time.Second)
+		defer cancelCheck()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		count, err := movieCollection.CountDocuments(checkCtx, excludeArchived(bson.M{"imdb_id": movieId}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check movie"})
+			return
+		}
+
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to read video"})
+			return
+		}
+		defer file.Close()
+
+		//A new key for every upload so players reading the previous file are not cut off while it is replaced
+		key := "videos/" + movieId + "/" + bson.NewObjectID().Hex() + extension
+
+		//The copy lasts as long as the client keeps the request open
+		if err := store.Put(c.Request.Context(), key, file); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store video", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var videosCollection *mongo.Collection = database.OpenCollection("videos", client)
+
+		var previous models.VideoAsset
+		err = videosCollection.FindOne(ctx, bson.M{"imdb_id": movieId}).Decode(&previous)
+
+		if err != nil && err != mongo.ErrNoDocuments {
+			store.Delete(ctx, key)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check previous video"})
+			return
+		}
+
+		video := models.VideoAsset{
+			ImdbID:       movieId,
+			Key:          key,
+			ContentType:  contentType,
+			Size:         fileHeader.Size,
+			OriginalName: path.Base(fileHeader.Filename),
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		}
+
+		if !previous.ID.IsZero() {
+			video.CreatedAt = previous.CreatedAt
+		}
+
+		opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+
+		if err := videosCollection.FindOneAndReplace(ctx, bson.M{"imdb_id": movieId}, video, opts).Decode(&video); err != nil {
+			store.Delete(ctx, key)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save video"})
+			return
+		}
+
+		if previous.Key != "" && previous.Key != key {
+			if err := store.Delete(ctx, previous.Key); err != nil {
+				log.Println("Warning: unable to delete replaced video", previous.Key, err)
+			}
+		}
+
+		c.JSON(http.StatusCreated, video)
+	}
+}
+
+// Function that returns the video record of a movie, writing a 404 response when the movie or its video do not exist
+func findMovieVideo(ctx context.Context, c *gin.Context, client *mongo.Client, movieId string) (models.VideoAsset, bool) {
+	var video models.VideoAsset
+
+	var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+	count, err := movieCollection.CountDocuments(ctx, excludeArchived(bson.M{"imdb_id": movieId}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check movie"})
+		return video, false
+	}
+
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return video, false
+	}
+
+	var videosCollection *mongo.Collection = database.OpenCollection("videos", client)
+
+	if err := videosCollection.FindOne(ctx, bson.M{"imdb_id": movieId}).Decode(&video); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie has no video"})
+		return video, false
+	}
+
+	return video, true
+}
+
+// Function that streams the video of a movie. Range requests are answered with 206 Partial Content so players can seek
+func StreamMovie(client *mongo.Client, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		video, ok := findMovieVideo(ctx, c, client, c.Param("imdb_id"))
+		cancel()
+
+		if !ok {
+			return
+		}
+
+		serveBlob(c, store, video.Key, video.ContentType, "private, max-age=3600")
+	}
+}
+
+// Function that writes a stored file on the response with http.ServeContent, which handles Range, If-Range and
+// conditional requests. Reading lasts as long as the client takes so it is bound to the request context only
+func serveBlob(c *gin.Context, store storage.BlobStore, key, contentType, cacheControl string) {
+	blob, err := store.Open(c.Request.Context(), key)
+
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer blob.Close()
+
+	info := blob.Info()
+
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	if contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+
+	c.Header("Cache-Control", cacheControl)
+	c.Header("Accept-Ranges", "bytes")
+
+	http.ServeContent(c.Writer, c.Request, "", info.ModTime, blob)
+}
+
+// Cache headers of HLS responses. Playlists are rebuilt when a package is registered again, segments never change
+const playlistCacheControl = "private, max-age=60"
+const segmentCacheControl = "private, max-age=86400"
+
+// Function that registers a directory of the blob store holding pre-segmented HLS renditions of a movie (Admin only).
+// Registering again replaces the previous package
+func RegisterHLSPackage(client *mongo.Client, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieId := c.Param("imdb_id")
+
+		var registration models.HLSRegistration
+
+		if err := c.ShouldBindJSON(&registration); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		if err := validate.Struct(registration); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		count, err := movieCollection.CountDocuments(ctx, excludeArchived(bson.M{"imdb_id": movieId}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check movie"})
+			return
+		}
+
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		pkg, err := media.ScanHLSPackage(ctx, store, registration)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid HLS directory", "details": err.Error()})
+			return
+		}
+
+		pkg.ImdbID = movieId
+		pkg.CreatedAt = time.Now()
+		pkg.UpdatedAt = time.Now()
+
+		var hlsCollection *mongo.Collection = database.OpenCollection("hls_packages", client)
+
+		var previous models.HLSPackage
+		if err := hlsCollection.FindOne(ctx, bson.M{"imdb_id": movieId}).Decode(&previous); err == nil {
+			pkg.CreatedAt = previous.CreatedAt
+		}
+
+		opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+
+		if err := hlsCollection.FindOneAndReplace(ctx, bson.M{"imdb_id": movieId}, pkg, opts).Decode(&pkg); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save HLS package"})
+			return
+		}
+
+		c.JSON(http.StatusOK, pkg)
+	}
+}
+
+// Function that returns the HLS package of a movie, writing a 404 response when the movie or its package do not exist
+func findHLSPackage(c *gin.Context, client *mongo.Client) (models.HLSPackage, bool) {
+	var pkg models.HLSPackage
+
+	ctx, cancel := context.WithTimeout(c, 100*time.Second)
+	defer cancel()
+
+	movieId := c.Param("imdb_id")
+
+	var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+	count, err := movieCollection.CountDocuments(ctx, excludeArchived(bson.M{"imdb_id": movieId}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check movie"})
+		return pkg, false
+	}
+
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return pkg, false
+	}
+
+	var hlsCollection *mongo.Collection = database.OpenCollection("hls_packages", client)
+
+	if err := hlsCollection.FindOne(ctx, bson.M{"imdb_id": movieId}).Decode(&pkg); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie has no HLS package"})
+		return pkg, false
+	}
+
+	return pkg, true
+}
+
+// Function that returns the master playlist of a movie listing every rendition
+func GetHLSMasterPlaylist(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pkg, ok := findHLSPackage(c, client)
+		if !ok {
+			return
+		}
+
+		c.Header("Cache-Control", playlistCacheControl)
+		c.Data(http.StatusOK, media.PlaylistContentType, []byte(media.MasterPlaylist(pkg, signedQuery(c))))
+	}
+}
+
+// Function that returns the playlist (index.m3u8) or a segment of a rendition. Only registered files are served
+func GetHLSFile(client *mongo.Client, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pkg, ok := findHLSPackage(c, client)
+		if !ok {
+			return
+		}
+
+		renditionName := c.Param("rendition")
+		file := c.Param("file")
+
+		for _, rendition := range pkg.Renditions {
+			if rendition.Name != renditionName {
+				continue
+			}
+
+			if file == media.VariantPlaylistName {
+				c.Header("Cache-Control", playlistCacheControl)
+				c.Data(http.StatusOK, media.PlaylistContentType, []byte(media.VariantPlaylist(pkg, rendition, signedQuery(c))))
+				return
+			}
+
+			if file == rendition.InitSegment || slices.Contains(rendition.Segments, file) {
+				key := pkg.Directory + "/" + rendition.Name + "/" + file
+				serveBlob(c, store, key, media.SegmentContentType(file), segmentCacheControl)
+				return
+			}
+		}
+
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+	}
+}
+
+// Prefix of the streaming routes guarded by SignedURLMiddleware instead of the access token cookie
+const signedStreamPrefix = "/signed/stream/"
+
+// How long playback URLs stay valid when PLAYBACK_URL_TTL (a Go duration such as 4h) is not set
+const defaultPlaybackURLTTL = 4 * time.Hour
+
+// Function that returns the query of a signed request so playlists carry the signature to the files they reference
+func signedQuery(c *gin.Context) string {
+	if c.Query(utils.SignedSignatureParam) == "" {
+		return ""
+	}
+	return c.Request.URL.RawQuery
+}
+
+// Function that returns how long playback URLs stay valid
+func playbackURLTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("PLAYBACK_URL_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultPlaybackURLTTL
+	}
+	return ttl
+}
+
+// Function that issues signed, expiring URLs to play the video and the HLS package of a movie
+func GetMoviePlayback(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieId := c.Param("imdb_id")
+
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		var movie models.Movie
+
+		if err := movieCollection.FindOne(ctx, excludeArchived(bson.M{"imdb_id": movieId})).Decode(&movie); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		playback := models.Playback{
+			ImdbID:    movieId,
+			YoutubeID: movie.YoutubeID,
+			ExpiresAt: time.Now().Add(playbackURLTTL()).UTC().Truncate(time.Second),
+		}
+
+		var videosCollection *mongo.Collection = database.OpenCollection("videos", client)
+
+		var video models.VideoAsset
+		err = videosCollection.FindOne(ctx, bson.M{"imdb_id": movieId}).Decode(&video)
+
+		if err != nil && err != mongo.ErrNoDocuments {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching movie video"})
+			return
+		}
+
+		if err == nil {
+			path := signedStreamPrefix + movieId
+
+			query, err := utils.GenerateSignedQuery(path, userId, playback.ExpiresAt)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign playback urls", "details": err.Error()})
+				return
+			}
+
+			playback.VideoURL = media.BaseURL() + path + "?" + query
+			playback.VideoContentType = video.ContentType
+		}
+
+		var hlsCollection *mongo.Collection = database.OpenCollection("hls_packages", client)
+
+		count, err := hlsCollection.CountDocuments(ctx, bson.M{"imdb_id": movieId})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching movie HLS package"})
+			return
+		}
+
+		if count > 0 {
+			//The whole package directory is signed so playlists and segments share the signature
+			prefix := signedStreamPrefix + movieId + "/hls/"
+
+			query, err := utils.GenerateSignedQuery(prefix, userId, playback.ExpiresAt)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign playback urls", "details": err.Error()})
+				return
+			}
+
+			playback.HLSURL = media.BaseURL() + prefix + "master.m3u8?" + query
+		}
+
+		c.JSON(http.StatusOK, playback)
+	}
+}
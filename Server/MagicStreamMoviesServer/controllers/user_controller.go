@@ -64,21 +64,6 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 		// Get collection
 		var userCollection *mongo.Collection = database.OpenCollection("users", client)
 
-		//Validate email from user is unique (Not on db already), count how many documents exist with email received from input
-		count, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
-
-		//Error that occurs when not possible to check if email already exist in any document in users collection in db
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing user"})
-			return
-		}
-
-		//User already exists
-		if count > 0 {
-			c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
-			return
-		}
-
 		//Create Unique User ID
 		user.UserID = bson.NewObjectID().Hex()
 		//Define created and updated at parameters
@@ -89,6 +74,12 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 
 		result, err := userCollection.InsertOne(ctx, user)
 
+		//Email is unique on users collection (unique index created by migrations), so an existing user fails the insert
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+			return
+		}
+
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 			return
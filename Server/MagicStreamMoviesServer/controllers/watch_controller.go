@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Watched fraction of a movie after which it is completed when WATCH_COMPLETED_THRESHOLD is not set
+const defaultCompletedThreshold = 0.9
+
+// Function that returns the watched fraction (between 0 and 1) after which a movie counts as completed
+func completedThreshold() float64 {
+	threshold, err := strconv.ParseFloat(os.Getenv("WATCH_COMPLETED_THRESHOLD"), 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		return defaultCompletedThreshold
+	}
+	return threshold
+}
+
+// Function that stores the playback position the player reports for the current user
+func UpdateWatchProgress(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieId := c.Param("imdb_id")
+
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		var report models.ProgressReport
+
+		if err := c.ShouldBindJSON(&report); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Input"})
+			return
+		}
+
+		if err := validate.Struct(report); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		count, err := movieCollection.CountDocuments(ctx, excludeArchived(bson.M{"imdb_id": movieId}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check movie"})
+			return
+		}
+
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		now := time.Now()
+		completed := float64(report.PositionSeconds)/float64(report.DurationSeconds) >= completedThreshold()
+
+		set := bson.M{
+			"position_seconds": report.PositionSeconds,
+			"duration_seconds": report.DurationSeconds,
+			"completed":        completed,
+			"updated_at":       now,
+		}
+		if completed {
+			set["completed_at"] = now
+		}
+
+		update := bson.M{
+			"$set":         set,
+			"$setOnInsert": bson.M{"user_id": userId, "imdb_id": movieId, "created_at": now},
+		}
+
+		var watchProgressCollection *mongo.Collection = database.OpenCollection("watch_progress", client)
+
+		opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+		var progress models.WatchProgress
+
+		err = watchProgressCollection.FindOneAndUpdate(ctx, bson.M{"user_id": userId, "imdb_id": movieId}, update, opts).Decode(&progress)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save progress"})
+			return
+		}
+
+		recordHistoryFromRequest(ctx, c, client, movieId, models.HistoryEventWatched)
+
+		c.JSON(http.StatusOK, progress)
+	}
+}
+
+// Function that returns the playback position of the current user on a movie so the player can resume it
+func GetWatchProgress(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var watchProgressCollection *mongo.Collection = database.OpenCollection("watch_progress", client)
+
+		var progress models.WatchProgress
+
+		if err := watchProgressCollection.FindOne(ctx, bson.M{"user_id": userId, "imdb_id": c.Param("imdb_id")}).Decode(&progress); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No progress for this movie"})
+			return
+		}
+
+		c.JSON(http.StatusOK, progress)
+	}
+}
+
+// Function that returns the movies the current user started and did not complete, most recently watched first
+func GetContinueWatching(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		limit := int64(20)
+		if value := c.Query("limit"); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed < 1 || parsed > 50 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+				return
+			}
+			limit = parsed
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var watchProgressCollection *mongo.Collection = database.OpenCollection("watch_progress", client)
+
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"user_id": userId, "completed": false, "position_seconds": bson.M{"$gt": 0}}}},
+			{{Key: "$sort", Value: bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}}},
+			//Archived movies are dropped by the inner match
+			{{Key: "$lookup", Value: bson.M{
+				"from":     "movies",
+				"let":      bson.M{"imdb_id": "$imdb_id"},
+				"pipeline": bson.A{bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$imdb_id", "$$imdb_id"}}, "archived": bson.M{"$ne": true}}}},
+				"as":       "movie",
+			}}},
+			{{Key: "$unwind", Value: "$movie"}},
+			{{Key: "$limit", Value: limit}},
+			{{Key: "$project", Value: bson.M{"movie": 1, "progress": "$$ROOT"}}},
+			{{Key: "$project", Value: bson.M{"progress.movie": 0}}},
+		}
+
+		cursor, err := watchProgressCollection.Aggregate(ctx, pipeline)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching continue watching"})
+			return
+		}
+
+		entries := []models.ContinueWatchingEntry{}
+		if err := cursor.All(ctx, &entries); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, entries)
+	}
+}
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Sort options of the watchlist mapped to the field of the listing documents they sort by
+var watchlistSortFields = map[string]string{
+	"":        "added_to_watchlist_at",
+	"added":   "added_to_watchlist_at",
+	"ranking": "ranking.ranking_value",
+}
+
+// Function that sets InWatchlist on movies for the authenticated user. Anonymous requests leave every flag false
+func markWatchlist(ctx context.Context, c *gin.Context, client *mongo.Client, movies ...*models.Movie) error {
+	userId, err := utils.GetUserIdFromContext(c)
+	if err != nil || len(movies) == 0 {
+		return nil
+	}
+
+	ids := bson.A{}
+	for _, movie := range movies {
+		ids = append(ids, movie.ImdbID)
+	}
+
+	var watchlistCollection *mongo.Collection = database.OpenCollection("watchlist", client)
+
+	result := watchlistCollection.Distinct(ctx, "imdb_id", bson.M{"user_id": userId, "imdb_id": bson.M{"$in": ids}})
+	if err := result.Err(); err != nil {
+		return err
+	}
+
+	var savedIds []string
+	if err := result.Decode(&savedIds); err != nil {
+		return err
+	}
+
+	saved := map[string]bool{}
+	for _, id := range savedIds {
+		saved[id] = true
+	}
+
+	for _, movie := range movies {
+		movie.InWatchlist = saved[movie.ImdbID]
+	}
+
+	return nil
+}
+
+// Function that adds a movie to the watchlist of the current user. Adding it again keeps its original position
+func AddToWatchlist(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		movieId := c.Param("imdb_id")
+
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		count, err := movieCollection.CountDocuments(ctx, excludeArchived(bson.M{"imdb_id": movieId}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check movie"})
+			return
+		}
+
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		var watchlistCollection *mongo.Collection = database.OpenCollection("watchlist", client)
+
+		entry := models.WatchlistEntry{UserID: userId, ImdbID: movieId, AddedAt: time.Now()}
+
+		result, err := watchlistCollection.InsertOne(ctx, entry)
+
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusOK, gin.H{"message": "Movie already on watchlist"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add movie to watchlist"})
+			return
+		}
+
+		entry.ID, _ = result.InsertedID.(bson.ObjectID)
+
+		c.JSON(http.StatusCreated, entry)
+	}
+}
+
+// Function that removes a movie from the watchlist of the current user
+func RemoveFromWatchlist(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var watchlistCollection *mongo.Collection = database.OpenCollection("watchlist", client)
+
+		result, err := watchlistCollection.DeleteOne(ctx, bson.M{"user_id": userId, "imdb_id": c.Param("imdb_id")})
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove movie from watchlist"})
+			return
+		}
+
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie is not on watchlist"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Movie removed from watchlist"})
+	}
+}
+
+// Function that returns one page of the watchlist of the current user, in the order movies were added unless sorted by ranking
+func GetWatchlist(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User Id not found in context"})
+			return
+		}
+
+		var query models.WatchlistQuery
+
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+			return
+		}
+
+		if err := validate.Struct(query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		keysetQuery, err := utils.NewKeysetQuery(watchlistSortFields[query.Sort], query.Order != "desc", query.Limit, query.Cursor)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var watchlistCollection *mongo.Collection = database.OpenCollection("watchlist", client)
+
+		//Every entry is replaced by its movie (archived ones are dropped) so pages hold full movie documents
+		stages := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"user_id": userId}}},
+			{{Key: "$lookup", Value: bson.M{
+				"from":     "movies",
+				"let":      bson.M{"imdb_id": "$imdb_id"},
+				"pipeline": bson.A{bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$imdb_id", "$$imdb_id"}}, "archived": bson.M{"$ne": true}}}},
+				"as":       "movie",
+			}}},
+			{{Key: "$unwind", Value: "$movie"}},
+			{{Key: "$replaceRoot", Value: bson.M{"newRoot": bson.M{"$mergeObjects": bson.A{"$movie", bson.M{"added_to_watchlist_at": "$added_at"}}}}}},
+		}
+
+		page, err := utils.AggregatePage[models.WatchlistMovie](ctx, watchlistCollection, stages, keysetQuery)
+
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor does not match sort option"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch watchlist"})
+			return
+		}
+
+		for i := range page.Items {
+			page.Items[i].InWatchlist = true
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
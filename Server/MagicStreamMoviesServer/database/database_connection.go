@@ -56,3 +56,8 @@ func OpenCollection(collectionName string, client *mongo.Client) *mongo.Collecti
 
 	return collection
 }
+
+// Function that returns the application database (DATABASE_NAME environment variable)
+func OpenDatabase(client *mongo.Client) *mongo.Database {
+	return client.Database(os.Getenv("DATABASE_NAME"))
+}
@@ -5,11 +5,16 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/commands"
 	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/migrations"
 	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/routes"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/sentiment"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/storage"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -39,6 +44,35 @@ func main() {
 		}
 	}()
 
+	//Run command line subcommand (for example: go run . import -file movies.csv) instead of the web server
+	if len(os.Args) > 1 {
+		if err := commands.Run(client, os.Args[1:]); err != nil {
+			log.Fatalf("%s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	//Unique and text indexes are created by migrations, the server can not run without them.
+	//With MIGRATE_ON_START=true pending migrations are applied at boot, otherwise the server refuses to start
+	migrateOnStart, _ := strconv.ParseBool(os.Getenv("MIGRATE_ON_START"))
+
+	pending, err := migrations.Pending(context.Background(), client)
+	if err != nil {
+		log.Fatalf("Failed to check database migrations: %v", err)
+	}
+
+	if len(pending) > 0 {
+		if !migrateOnStart {
+			log.Fatalf("%d database migrations pending, run: go run . migrate up (or set MIGRATE_ON_START=true)", len(pending))
+		}
+
+		ran, err := migrations.Up(context.Background(), client, 0)
+		if err != nil {
+			log.Fatalf("Failed to apply database migrations: %v", err)
+		}
+		log.Printf("Applied %d database migrations", len(ran))
+	}
+
 	//Build URLS that we can permit to access the server
 	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
 
@@ -68,8 +102,27 @@ func main() {
 	config.MaxAge = 12 * time.Hour
 	router.Use(cors.New(config))
 
-	routes.SetupUnProtectedRoutes(router, client)
-	routes.SetupProtectedRoutes(router, client)
+	//Blob store holding uploaded posters, backdrops and videos
+	mediaStore, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to open media storage: %v", err)
+	}
+
+	//Language model ranking reviews, provider and model come from LLM_PROVIDER and LLM_MODEL
+	llm, err := sentiment.NewModelFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to open language model: %v", err)
+	}
+
+	//Classifier ranking admin and user reviews
+	reviewClassifier, err := sentiment.NewFromEnv(llm)
+	if err != nil {
+		log.Fatalf("Failed to open review classifier: %v", err)
+	}
+
+	routes.SetupUnProtectedRoutes(router, client, mediaStore)
+	routes.SetupSignedRoutes(router, client, mediaStore)
+	routes.SetupProtectedRoutes(router, client, mediaStore, reviewClassifier)
 
 	if err := router.Run(":8080"); err != nil {
 		fmt.Println("Failed to start server", err)
@@ -0,0 +1,296 @@
+package media
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/storage"
+)
+
+// Name of the playlist of every rendition on the HLS routes
+const VariantPlaylistName = "index.m3u8"
+
+// Content type of HLS playlists
+const PlaylistContentType = "application/vnd.apple.mpegurl"
+
+// Content type of every accepted segment file extension
+var segmentContentTypes = map[string]string{
+	".ts":  "video/mp2t",
+	".m4s": "video/iso.segment",
+	".aac": "audio/aac",
+}
+
+// Names of fMP4 initialization segments
+var initSegmentNames = map[string]bool{"init.mp4": true, "init.m4s": true}
+
+// Function that returns the content type of a segment or initialization segment file
+func SegmentContentType(name string) string {
+	if initSegmentNames[name] {
+		return "video/mp4"
+	}
+	return segmentContentTypes[path.Ext(name)]
+}
+
+// Largest uploaded variant playlist read for segment durations
+const maxUploadedPlaylistSize = 4 << 20
+
+// Function that lists the segments of every rendition of a registration and returns the package to store.
+// Segments are sorted by natural order so seg2.ts plays before seg10.ts. Their durations come from the registration,
+// otherwise from an index.m3u8 uploaded in the rendition directory, otherwise every segment lasts SegmentDuration
+func ScanHLSPackage(ctx context.Context, store storage.BlobStore, registration models.HLSRegistration) (models.HLSPackage, error) {
+	pkg := models.HLSPackage{
+		Directory:       strings.Trim(path.Clean(registration.Directory), "/"),
+		SegmentDuration: registration.SegmentDuration,
+		Renditions:      []models.HLSRendition{},
+	}
+
+	seen := map[string]bool{}
+
+	for _, renditionRegistration := range registration.Renditions {
+		if seen[renditionRegistration.Name] {
+			return pkg, fmt.Errorf("rendition %s is registered twice", renditionRegistration.Name)
+		}
+		seen[renditionRegistration.Name] = true
+
+		if resolution := renditionRegistration.Resolution; resolution != "" {
+			var width, height int
+			if _, err := fmt.Sscanf(resolution, "%dx%d", &width, &height); err != nil || width < 1 || height < 1 {
+				return pkg, fmt.Errorf("invalid resolution %q of rendition %s, expected WIDTHxHEIGHT", resolution, renditionRegistration.Name)
+			}
+		}
+
+		prefix := pkg.Directory + "/" + renditionRegistration.Name + "/"
+
+		keys, err := store.List(ctx, prefix)
+		if err != nil {
+			return pkg, err
+		}
+
+		rendition := models.HLSRendition{
+			Name:       renditionRegistration.Name,
+			Bandwidth:  renditionRegistration.Bandwidth,
+			Resolution: renditionRegistration.Resolution,
+			Codecs:     renditionRegistration.Codecs,
+			Segments:   []string{},
+		}
+
+		for _, key := range keys {
+			name := strings.TrimPrefix(key, prefix)
+
+			//Nested directories are not part of the rendition
+			if strings.Contains(name, "/") {
+				continue
+			}
+
+			if initSegmentNames[name] {
+				rendition.InitSegment = name
+				continue
+			}
+
+			if _, ok := segmentContentTypes[path.Ext(name)]; ok {
+				rendition.Segments = append(rendition.Segments, name)
+			}
+		}
+
+		if len(rendition.Segments) == 0 {
+			return pkg, fmt.Errorf("directory %s has no segments", prefix)
+		}
+
+		sort.SliceStable(rendition.Segments, func(i, j int) bool {
+			return naturalLess(rendition.Segments[i], rendition.Segments[j])
+		})
+
+		rendition.SegmentDurations, err = segmentDurations(ctx, store, prefix, renditionRegistration, rendition.Segments)
+		if err != nil {
+			return pkg, err
+		}
+
+		pkg.Renditions = append(pkg.Renditions, rendition)
+	}
+
+	return pkg, nil
+}
+
+// Function that returns the duration of every segment of a rendition, nil when they all last the package SegmentDuration
+func segmentDurations(ctx context.Context, store storage.BlobStore, prefix string, registration models.HLSRenditionRegistration, segments []string) ([]float64, error) {
+	if len(registration.SegmentDurations) > 0 {
+		if len(registration.SegmentDurations) != len(segments) {
+			return nil, fmt.Errorf("rendition %s has %d segments but %d segment durations", registration.Name, len(segments), len(registration.SegmentDurations))
+		}
+		return registration.SegmentDurations, nil
+	}
+
+	blob, err := store.Open(ctx, prefix+VariantPlaylistName)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+
+	listed, err := parsePlaylistDurations(io.LimitReader(blob, maxUploadedPlaylistSize))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s of rendition %s: %w", VariantPlaylistName, registration.Name, err)
+	}
+
+	durations := make([]float64, len(segments))
+	for i, segment := range segments {
+		duration, ok := listed[segment]
+		if !ok {
+			return nil, fmt.Errorf("segment %s of rendition %s is missing from its %s", segment, registration.Name, VariantPlaylistName)
+		}
+		durations[i] = duration
+	}
+
+	return durations, nil
+}
+
+// Function that reads the #EXTINF duration of every segment of a variant playlist, keyed by segment file name
+func parsePlaylistDurations(r io.Reader) (map[string]float64, error) {
+	durations := map[string]float64{}
+	pending := -1.0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if value, ok := strings.CutPrefix(line, "#EXTINF:"); ok {
+			value, _, _ = strings.Cut(value, ",")
+			duration, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || duration <= 0 || duration > 60 {
+				return nil, fmt.Errorf("invalid segment duration %q", value)
+			}
+			pending = duration
+			continue
+		}
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		//Segment URI, the duration belongs to the file name whatever the path or query
+		if pending > 0 {
+			uri, _, _ := strings.Cut(line, "?")
+			durations[path.Base(uri)] = pending
+			pending = -1
+		}
+	}
+
+	return durations, scanner.Err()
+}
+
+// Function that returns the master playlist of a package, listing renditions by decreasing bandwidth. query is appended to
+// every URI so signed playlists keep the signature on the files they reference
+func MasterPlaylist(pkg models.HLSPackage, query string) string {
+	renditions := append([]models.HLSRendition(nil), pkg.Renditions...)
+	sort.SliceStable(renditions, func(i, j int) bool { return renditions[i].Bandwidth > renditions[j].Bandwidth })
+
+	var playlist strings.Builder
+
+	playlist.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+
+	for _, rendition := range renditions {
+		playlist.WriteString("#EXT-X-STREAM-INF:BANDWIDTH=" + strconv.Itoa(rendition.Bandwidth))
+		if rendition.Resolution != "" {
+			playlist.WriteString(",RESOLUTION=" + rendition.Resolution)
+		}
+		if rendition.Codecs != "" {
+			playlist.WriteString(`,CODECS="` + rendition.Codecs + `"`)
+		}
+		playlist.WriteString("\n" + withQuery(rendition.Name+"/"+VariantPlaylistName, query) + "\n")
+	}
+
+	return playlist.String()
+}
+
+// Function that returns the video on demand playlist of a rendition. Segments without their own duration last the
+// package SegmentDuration, the target duration is the longest segment rounded up
+func VariantPlaylist(pkg models.HLSPackage, rendition models.HLSRendition, query string) string {
+	//fMP4 segments need version 7, MPEG-TS segments play with version 3
+	version := 3
+	if rendition.InitSegment != "" {
+		version = 7
+	}
+
+	durations := make([]float64, len(rendition.Segments))
+	target := 0.0
+	for i := range rendition.Segments {
+		durations[i] = pkg.SegmentDuration
+		if i < len(rendition.SegmentDurations) {
+			durations[i] = rendition.SegmentDurations[i]
+		}
+		target = math.Max(target, durations[i])
+	}
+
+	var playlist strings.Builder
+
+	fmt.Fprintf(&playlist, "#EXTM3U\n#EXT-X-VERSION:%d\n#EXT-X-TARGETDURATION:%d\n", version, int(math.Ceil(target)))
+	playlist.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n")
+
+	if rendition.InitSegment != "" {
+		playlist.WriteString(`#EXT-X-MAP:URI="` + withQuery(rendition.InitSegment, query) + `"` + "\n")
+	}
+
+	for i, segment := range rendition.Segments {
+		playlist.WriteString("#EXTINF:" + strconv.FormatFloat(durations[i], 'f', 3, 64) + ",\n" + withQuery(segment, query) + "\n")
+	}
+
+	playlist.WriteString("#EXT-X-ENDLIST\n")
+
+	return playlist.String()
+}
+
+func withQuery(uri, query string) string {
+	if query == "" {
+		return uri
+	}
+	return uri + "?" + query
+}
+
+// Function that compares names treating runs of digits as numbers
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		aChunk, aRest, aNumber := nextChunk(a)
+		bChunk, bRest, bNumber := nextChunk(b)
+
+		if aNumber && bNumber {
+			aTrimmed := strings.TrimLeft(aChunk, "0")
+			bTrimmed := strings.TrimLeft(bChunk, "0")
+			if len(aTrimmed) != len(bTrimmed) {
+				return len(aTrimmed) < len(bTrimmed)
+			}
+			if aTrimmed != bTrimmed {
+				return aTrimmed < bTrimmed
+			}
+		} else if aChunk != bChunk {
+			return aChunk < bChunk
+		}
+
+		a, b = aRest, bRest
+	}
+
+	return len(a) < len(b)
+}
+
+// Function that splits the leading run of digits or non digits of s
+func nextChunk(s string) (chunk string, rest string, number bool) {
+	number = unicode.IsDigit(rune(s[0]))
+
+	end := 1
+	for end < len(s) && unicode.IsDigit(rune(s[end])) == number {
+		end++
+	}
+
+	return s[:end], s[end:], number
+}
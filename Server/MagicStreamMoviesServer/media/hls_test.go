@@ -0,0 +1,296 @@
+package media
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/storage"
+)
+
+// Function that returns a blob store on a temporary directory holding a tiny synthetic file for every key
+func newSegmentStore(t *testing.T, files map[string]string) storage.BlobStore {
+	t.Helper()
+
+	store, err := storage.NewFileSystemStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, content := range files {
+		if err := store.Put(context.Background(), key, strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return store
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"seg2.ts", "seg10.ts", true},
+		{"seg10.ts", "seg2.ts", false},
+		{"seg002.ts", "seg10.ts", true},
+		{"seg1.ts", "seg1.ts", false},
+		{"a.ts", "b.ts", true},
+		{"seg1.ts", "seg1a.ts", true},
+		{"seg9", "seg9.ts", true},
+		{"", "seg0.ts", true},
+	}
+
+	for _, test := range tests {
+		if got := naturalLess(test.a, test.b); got != test.want {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+
+	names := []string{"seg10.ts", "seg1.ts", "seg100.ts", "seg2.ts", "seg01.ts"}
+	sort.SliceStable(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+
+	want := []string{"seg1.ts", "seg01.ts", "seg2.ts", "seg10.ts", "seg100.ts"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("sorted names = %v, want %v", names, want)
+	}
+}
+
+func TestScanHLSPackage(t *testing.T) {
+	store := newSegmentStore(t, map[string]string{
+		"hls/tt1/720p/init.mp4":      "init",
+		"hls/tt1/720p/seg10.m4s":     "s",
+		"hls/tt1/720p/seg2.m4s":      "s",
+		"hls/tt1/720p/seg1.m4s":      "s",
+		"hls/tt1/720p/notes.txt":     "ignored",
+		"hls/tt1/720p/old/seg0.m4s":  "nested",
+		"hls/tt1/360p/seg1.ts":       "s",
+		"hls/tt1/360p/seg0.ts":       "s",
+		"hls/tt1/360p/thumbnail.jpg": "ignored",
+	})
+
+	registration := models.HLSRegistration{
+		Directory:       "/hls/tt1/",
+		SegmentDuration: 6,
+		Renditions: []models.HLSRenditionRegistration{
+			{Name: "720p", Bandwidth: 2500000, Resolution: "1280x720"},
+			{Name: "360p", Bandwidth: 800000},
+		},
+	}
+
+	pkg, err := ScanHLSPackage(context.Background(), store, registration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pkg.Directory != "hls/tt1" {
+		t.Errorf("directory = %q, want hls/tt1", pkg.Directory)
+	}
+
+	if len(pkg.Renditions) != 2 {
+		t.Fatalf("got %d renditions, want 2", len(pkg.Renditions))
+	}
+
+	hd := pkg.Renditions[0]
+	if hd.InitSegment != "init.mp4" {
+		t.Errorf("init segment = %q, want init.mp4", hd.InitSegment)
+	}
+	if want := []string{"seg1.m4s", "seg2.m4s", "seg10.m4s"}; !reflect.DeepEqual(hd.Segments, want) {
+		t.Errorf("720p segments = %v, want %v", hd.Segments, want)
+	}
+	if hd.SegmentDurations != nil {
+		t.Errorf("720p durations = %v, want none", hd.SegmentDurations)
+	}
+
+	if want := []string{"seg0.ts", "seg1.ts"}; !reflect.DeepEqual(pkg.Renditions[1].Segments, want) {
+		t.Errorf("360p segments = %v, want %v", pkg.Renditions[1].Segments, want)
+	}
+}
+
+func TestScanHLSPackageErrors(t *testing.T) {
+	store := newSegmentStore(t, map[string]string{
+		"hls/tt1/720p/seg0.ts":   "s",
+		"hls/tt1/720p/seg1.ts":   "s",
+		"hls/tt1/empty/info.txt": "no segments",
+	})
+
+	tests := []struct {
+		name       string
+		renditions []models.HLSRenditionRegistration
+		want       string
+	}{
+		{
+			name:       "duplicate rendition",
+			renditions: []models.HLSRenditionRegistration{{Name: "720p", Bandwidth: 1}, {Name: "720p", Bandwidth: 1}},
+			want:       "registered twice",
+		},
+		{
+			name:       "invalid resolution",
+			renditions: []models.HLSRenditionRegistration{{Name: "720p", Bandwidth: 1, Resolution: "720p"}},
+			want:       "invalid resolution",
+		},
+		{
+			name:       "no segments",
+			renditions: []models.HLSRenditionRegistration{{Name: "empty", Bandwidth: 1}},
+			want:       "has no segments",
+		},
+		{
+			name:       "missing directory",
+			renditions: []models.HLSRenditionRegistration{{Name: "1080p", Bandwidth: 1}},
+			want:       "has no segments",
+		},
+		{
+			name:       "duration count mismatch",
+			renditions: []models.HLSRenditionRegistration{{Name: "720p", Bandwidth: 1, SegmentDurations: []float64{6}}},
+			want:       "2 segments but 1 segment durations",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			registration := models.HLSRegistration{Directory: "hls/tt1", SegmentDuration: 6, Renditions: test.renditions}
+
+			_, err := ScanHLSPackage(context.Background(), store, registration)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("error = %v, want it to contain %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestScanHLSPackageDurations(t *testing.T) {
+	uploaded := "#EXTM3U\n#EXT-X-TARGETDURATION:7\n" +
+		"#EXTINF:6.006,\nseg0.ts\n" +
+		"#EXTINF:4.2,\nhttps://cdn.example.com/hls/seg1.ts?token=abc\n" +
+		"#EXTINF:6.5,\nseg2.ts\n#EXT-X-ENDLIST\n"
+
+	files := map[string]string{
+		"hls/tt1/720p/seg0.ts":    "s",
+		"hls/tt1/720p/seg1.ts":    "s",
+		"hls/tt1/720p/seg2.ts":    "s",
+		"hls/tt1/720p/index.m3u8": uploaded,
+	}
+
+	t.Run("registered durations", func(t *testing.T) {
+		store := newSegmentStore(t, files)
+		registration := models.HLSRegistration{Directory: "hls/tt1", SegmentDuration: 6, Renditions: []models.HLSRenditionRegistration{
+			{Name: "720p", Bandwidth: 1, SegmentDurations: []float64{5, 5, 2.5}},
+		}}
+
+		pkg, err := ScanHLSPackage(context.Background(), store, registration)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := []float64{5, 5, 2.5}; !reflect.DeepEqual(pkg.Renditions[0].SegmentDurations, want) {
+			t.Errorf("durations = %v, want %v", pkg.Renditions[0].SegmentDurations, want)
+		}
+	})
+
+	t.Run("uploaded playlist", func(t *testing.T) {
+		store := newSegmentStore(t, files)
+		registration := models.HLSRegistration{Directory: "hls/tt1", SegmentDuration: 6, Renditions: []models.HLSRenditionRegistration{
+			{Name: "720p", Bandwidth: 1},
+		}}
+
+		pkg, err := ScanHLSPackage(context.Background(), store, registration)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := []string{"seg0.ts", "seg1.ts", "seg2.ts"}; !reflect.DeepEqual(pkg.Renditions[0].Segments, want) {
+			t.Errorf("segments = %v, want %v", pkg.Renditions[0].Segments, want)
+		}
+		if want := []float64{6.006, 4.2, 6.5}; !reflect.DeepEqual(pkg.Renditions[0].SegmentDurations, want) {
+			t.Errorf("durations = %v, want %v", pkg.Renditions[0].SegmentDurations, want)
+		}
+	})
+
+	t.Run("segment missing from uploaded playlist", func(t *testing.T) {
+		store := newSegmentStore(t, map[string]string{
+			"hls/tt1/720p/seg0.ts":    "s",
+			"hls/tt1/720p/seg1.ts":    "s",
+			"hls/tt1/720p/index.m3u8": "#EXTM3U\n#EXTINF:6,\nseg0.ts\n",
+		})
+		registration := models.HLSRegistration{Directory: "hls/tt1", SegmentDuration: 6, Renditions: []models.HLSRenditionRegistration{
+			{Name: "720p", Bandwidth: 1},
+		}}
+
+		_, err := ScanHLSPackage(context.Background(), store, registration)
+		if err == nil || !strings.Contains(err.Error(), "seg1.ts") {
+			t.Errorf("error = %v, want it to name seg1.ts", err)
+		}
+	})
+
+	t.Run("invalid uploaded duration", func(t *testing.T) {
+		store := newSegmentStore(t, map[string]string{
+			"hls/tt1/720p/seg0.ts":    "s",
+			"hls/tt1/720p/index.m3u8": "#EXTM3U\n#EXTINF:soon,\nseg0.ts\n",
+		})
+		registration := models.HLSRegistration{Directory: "hls/tt1", SegmentDuration: 6, Renditions: []models.HLSRenditionRegistration{
+			{Name: "720p", Bandwidth: 1},
+		}}
+
+		_, err := ScanHLSPackage(context.Background(), store, registration)
+		if err == nil || !strings.Contains(err.Error(), "invalid segment duration") {
+			t.Errorf("error = %v, want an invalid segment duration", err)
+		}
+	})
+}
+
+func TestMasterPlaylist(t *testing.T) {
+	pkg := models.HLSPackage{Renditions: []models.HLSRendition{
+		{Name: "360p", Bandwidth: 800000},
+		{Name: "1080p", Bandwidth: 5000000, Resolution: "1920x1080", Codecs: "avc1.640028,mp4a.40.2"},
+		{Name: "720p", Bandwidth: 2500000, Resolution: "1280x720"},
+	}}
+
+	want := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-INDEPENDENT-SEGMENTS\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=5000000,RESOLUTION=1920x1080,CODECS=\"avc1.640028,mp4a.40.2\"\n1080p/index.m3u8?sig=x\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=2500000,RESOLUTION=1280x720\n720p/index.m3u8?sig=x\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=800000\n360p/index.m3u8?sig=x\n"
+
+	if got := MasterPlaylist(pkg, "sig=x"); got != want {
+		t.Errorf("master playlist =\n%s\nwant\n%s", got, want)
+	}
+
+	//Sorting the playlist must not reorder the stored renditions
+	if pkg.Renditions[0].Name != "360p" {
+		t.Errorf("renditions of the package were reordered")
+	}
+}
+
+func TestVariantPlaylist(t *testing.T) {
+	pkg := models.HLSPackage{SegmentDuration: 6}
+
+	t.Run("package duration", func(t *testing.T) {
+		rendition := models.HLSRendition{Name: "360p", Segments: []string{"seg0.ts", "seg1.ts"}}
+
+		want := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n" +
+			"#EXTINF:6.000,\nseg0.ts\n#EXTINF:6.000,\nseg1.ts\n#EXT-X-ENDLIST\n"
+
+		if got := VariantPlaylist(pkg, rendition, ""); got != want {
+			t.Errorf("variant playlist =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("segment durations", func(t *testing.T) {
+		rendition := models.HLSRendition{
+			Name:             "720p",
+			InitSegment:      "init.mp4",
+			Segments:         []string{"seg1.m4s", "seg2.m4s", "seg3.m4s"},
+			SegmentDurations: []float64{6.006, 7.2, 1.5},
+		}
+
+		want := "#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:8\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n" +
+			"#EXT-X-MAP:URI=\"init.mp4?sig=x\"\n" +
+			"#EXTINF:6.006,\nseg1.m4s?sig=x\n#EXTINF:7.200,\nseg2.m4s?sig=x\n#EXTINF:1.500,\nseg3.m4s?sig=x\n#EXT-X-ENDLIST\n"
+
+		if got := VariantPlaylist(pkg, rendition, "sig=x"); got != want {
+			t.Errorf("variant playlist =\n%s\nwant\n%s", got, want)
+		}
+	})
+}
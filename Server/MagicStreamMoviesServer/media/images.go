@@ -0,0 +1,201 @@
+// Package media processes uploaded media before it is written on the blob store and builds the public URLs of stored files
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/storage"
+)
+
+// Widths of the thumbnails generated for every image, widths larger than the original are skipped
+var ThumbnailWidths = []int{780, 342, 185, 92}
+
+// Largest image accepted by an upload
+const MaxImageSize = 10 << 20
+
+// Most pixels an uploaded image can have. Dimensions are read from the header before decoding so a small file
+// declaring a huge image can not exhaust memory
+const MaxImagePixels = 40_000_000
+
+// Route prefix serving the files of the blob store
+const AssetsPath = "/assets/"
+
+// Quality of generated JPEG thumbnails
+const jpegQuality = 85
+
+var ErrUnsupportedImage = errors.New("unsupported image, upload a JPEG or PNG file")
+var ErrImageTooLarge = errors.New("image has too many pixels")
+
+// Function that returns the public address of this server (MEDIA_BASE_URL environment variable) without trailing slash
+func BaseURL() string {
+	baseURL := os.Getenv("MEDIA_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	return strings.TrimRight(baseURL, "/")
+}
+
+// Function that returns the absolute URL of a stored image
+func PublicURL(key string) string {
+	return BaseURL() + AssetsPath + key
+}
+
+// Function that stores an uploaded JPEG or PNG image with its thumbnails under images/<sha256>/ and returns its description.
+// Files are addressed by content so uploading the same image again only rewrites identical files
+func SaveImage(ctx context.Context, store storage.BlobStore, data []byte) (models.ImageAsset, error) {
+	var asset models.ImageAsset
+
+	contentType := http.DetectContentType(data)
+
+	var extension string
+	switch contentType {
+	case "image/jpeg":
+		extension = ".jpg"
+	case "image/png":
+		extension = ".png"
+	default:
+		return asset, ErrUnsupportedImage
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return asset, ErrUnsupportedImage
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return asset, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return asset, ErrUnsupportedImage
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	prefix := "images/" + hash + "/"
+
+	asset.Hash = hash
+	asset.ContentType = contentType
+	asset.Width = img.Bounds().Dx()
+	asset.Height = img.Bounds().Dy()
+	asset.Size = int64(len(data))
+	asset.Key = prefix + "original" + extension
+	asset.URL = PublicURL(asset.Key)
+	asset.Thumbnails = []models.Thumbnail{}
+
+	if err := store.Put(ctx, asset.Key, bytes.NewReader(data)); err != nil {
+		return asset, err
+	}
+
+	//Every thumbnail is resized from the previous (larger) one, much cheaper than starting from the original each time
+	source := toRGBA(img)
+	for _, width := range ThumbnailWidths {
+		if width >= source.Bounds().Dx() {
+			continue
+		}
+
+		thumbnail := resize(source, width)
+		source = thumbnail
+
+		var encoded bytes.Buffer
+		if extension == ".png" {
+			err = png.Encode(&encoded, thumbnail)
+		} else {
+			err = jpeg.Encode(&encoded, thumbnail, &jpeg.Options{Quality: jpegQuality})
+		}
+		if err != nil {
+			return asset, err
+		}
+
+		key := prefix + "w" + strconv.Itoa(width) + extension
+		if err := store.Put(ctx, key, &encoded); err != nil {
+			return asset, err
+		}
+
+		asset.Thumbnails = append(asset.Thumbnails, models.Thumbnail{
+			Width:  thumbnail.Bounds().Dx(),
+			Height: thumbnail.Bounds().Dy(),
+			Key:    key,
+			URL:    PublicURL(key),
+		})
+	}
+
+	return asset, nil
+}
+
+// Function that returns img as RGBA so its pixels can be read straight from Pix
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	return rgba
+}
+
+// Function that scales src down to width keeping its aspect ratio. Every destination pixel is the average of the
+// source pixels it covers (box filter), good enough for downscaling posters
+func resize(src *image.RGBA, width int) *image.RGBA {
+	bounds := src.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := y * bounds.Dy() / height
+		y1 := (y + 1) * bounds.Dy() / height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+			x0 := x * bounds.Dx() / width
+			x1 := (x + 1) * bounds.Dx() / width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(src.Pix[offset])
+					g += uint64(src.Pix[offset+1])
+					b += uint64(src.Pix[offset+2])
+					a += uint64(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
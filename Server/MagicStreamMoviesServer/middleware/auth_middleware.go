@@ -44,3 +44,58 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 
 }
+
+// Gin handler function that only lets users with the ADMIN role reach the endpoint. Must run after AuthMiddleware
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, err := utils.GetRoleFromContext(c)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Role not found in context"})
+			c.Abort()
+			return
+		}
+
+		if role != "ADMIN" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User must be part of the admin role"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Gin handler function that grants access to routes serving files when the URL carries a valid signature (see
+// utils.GenerateSignedQuery). It replaces AuthMiddleware on those routes so links work on players that can not send cookies
+func SignedURLMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		details, err := utils.ValidateSignedURL(c.Request.URL.Path, c.Request.URL.Query())
+
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Set("userId", details.UserId)
+		c.Next()
+	}
+}
+
+// Gin handler function for public endpoints that personalise their response. It sets userId and role when the request
+// carries a valid access token but, unlike AuthMiddleware, lets anonymous requests through
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := utils.GetAccessToken(c)
+
+		if err == nil && token != "" {
+			if claims, err := utils.ValidateToken(token); err == nil {
+				c.Set("userId", claims.UserId)
+				c.Set("role", claims.Role)
+			}
+		}
+
+		c.Next()
+	}
+}
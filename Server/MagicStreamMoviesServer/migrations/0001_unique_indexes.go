@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Unique indexes so duplicated users and movies are rejected by the database instead of racy existence checks
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "unique_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			err := createIndexes(ctx, db, "users",
+				mongo.IndexModel{Keys: bson.D{{Key: "email", Value: 1}}, Options: named("email_unique").SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: named("user_id_unique").SetUnique(true)},
+			)
+
+			if err != nil {
+				return err
+			}
+
+			return createIndexes(ctx, db, "movies",
+				mongo.IndexModel{Keys: bson.D{{Key: "imdb_id", Value: 1}}, Options: named("imdb_id_unique").SetUnique(true)},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexes(ctx, db, "users", "email_unique", "user_id_unique"); err != nil {
+				return err
+			}
+			return dropIndexes(ctx, db, "movies", "imdb_id_unique")
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Indexes backing the filters and sort options of the movies listing
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "movie_listing_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "movies",
+				//Sort by title (also serves title prefix filter), _id breaks ties between equal titles
+				mongo.IndexModel{Keys: bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}}, Options: named("title_id")},
+				//Sort and filter by ranking value
+				mongo.IndexModel{Keys: bson.D{{Key: "ranking.ranking_value", Value: 1}, {Key: "_id", Value: 1}}, Options: named("ranking_value_id")},
+				//Filter by ranking name
+				mongo.IndexModel{Keys: bson.D{{Key: "ranking.ranking_name", Value: 1}}, Options: named("ranking_name")},
+				//Filter by genre name (multikey index since genre is an array)
+				mongo.IndexModel{Keys: bson.D{{Key: "genre.genre_name", Value: 1}}, Options: named("genre_name")},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "movies", "title_id", "ranking_value_id", "ranking_name", "genre_name")
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Text index used by the movies search. Title matches weight more than genre names and genre names more than the review
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "movie_text_index",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "movies", mongo.IndexModel{
+				Keys: bson.D{{Key: "title", Value: "text"}, {Key: "genre.genre_name", Value: "text"}, {Key: "admin_review", Value: "text"}},
+				Options: named("movie_text").SetWeights(bson.D{
+					{Key: "title", Value: 10},
+					{Key: "genre.genre_name", Value: 5},
+					{Key: "admin_review", Value: 1},
+				}),
+			})
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "movies", "movie_text")
+		},
+	})
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Unique genre ids and names, plus the indexes used to cascade genre renames and deletes to embedded copies
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "genre_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			err := createIndexes(ctx, db, "genres",
+				mongo.IndexModel{Keys: bson.D{{Key: "genre_id", Value: 1}}, Options: named("genre_id_unique").SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "genre_name", Value: 1}}, Options: named("genre_name_unique").SetUnique(true)},
+			)
+			if err != nil {
+				return err
+			}
+
+			err = createIndexes(ctx, db, "movies",
+				mongo.IndexModel{Keys: bson.D{{Key: "genre.genre_id", Value: 1}}, Options: named("genre_id")},
+			)
+			if err != nil {
+				return err
+			}
+
+			return createIndexes(ctx, db, "users",
+				mongo.IndexModel{Keys: bson.D{{Key: "favourite_genres.genre_id", Value: 1}}, Options: named("favourite_genre_id")},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexes(ctx, db, "genres", "genre_id_unique", "genre_name_unique"); err != nil {
+				return err
+			}
+			if err := dropIndexes(ctx, db, "movies", "genre_id"); err != nil {
+				return err
+			}
+			return dropIndexes(ctx, db, "users", "favourite_genre_id")
+		},
+	})
+}
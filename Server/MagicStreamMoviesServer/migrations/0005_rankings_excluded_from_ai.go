@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Replaces the magic ranking value 999 with the explicit excluded_from_ai flag and makes ranking values and names unique
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "rankings_excluded_from_ai",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("rankings").UpdateMany(ctx,
+				bson.M{"ranking_value": 999},
+				bson.M{"$set": bson.M{"excluded_from_ai": true}})
+			if err != nil {
+				return err
+			}
+
+			return createIndexes(ctx, db, "rankings",
+				mongo.IndexModel{Keys: bson.D{{Key: "ranking_value", Value: 1}}, Options: named("ranking_value_unique").SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "ranking_name", Value: 1}}, Options: named("ranking_name_unique").SetUnique(true)},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexes(ctx, db, "rankings", "ranking_value_unique", "ranking_name_unique"); err != nil {
+				return err
+			}
+
+			_, err := db.Collection("rankings").UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"excluded_from_ai": ""}})
+			return err
+		},
+	})
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Indexes backing the metadata filters (release year, runtime, languages, content rating, directors and cast)
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "movie_metadata_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "movies",
+				//Also backs sorting by release date
+				mongo.IndexModel{Keys: bson.D{{Key: "release_date", Value: 1}, {Key: "_id", Value: 1}}, Options: named("release_date_id")},
+				mongo.IndexModel{Keys: bson.D{{Key: "runtime_minutes", Value: 1}}, Options: named("runtime_minutes")},
+				mongo.IndexModel{Keys: bson.D{{Key: "original_language", Value: 1}}, Options: named("original_language")},
+				mongo.IndexModel{Keys: bson.D{{Key: "spoken_languages", Value: 1}}, Options: named("spoken_languages")},
+				mongo.IndexModel{Keys: bson.D{{Key: "content_rating", Value: 1}}, Options: named("content_rating")},
+				mongo.IndexModel{Keys: bson.D{{Key: "directors", Value: 1}}, Options: named("directors")},
+				mongo.IndexModel{Keys: bson.D{{Key: "cast", Value: 1}}, Options: named("cast")},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "movies", "release_date_id", "runtime_minutes", "original_language",
+				"spoken_languages", "content_rating", "directors", "cast")
+		},
+	})
+}
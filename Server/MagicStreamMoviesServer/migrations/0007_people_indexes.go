@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Unique person ids, person names lookup and the index used to find the movies crediting a person
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "people_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			err := createIndexes(ctx, db, "people",
+				mongo.IndexModel{Keys: bson.D{{Key: "person_id", Value: 1}}, Options: named("person_id_unique").SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "name", Value: 1}}, Options: named("name")},
+			)
+			if err != nil {
+				return err
+			}
+
+			return createIndexes(ctx, db, "movies",
+				mongo.IndexModel{Keys: bson.D{{Key: "credits.person_id", Value: 1}, {Key: "credits.role", Value: 1}}, Options: named("credits_person_role")},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexes(ctx, db, "people", "person_id_unique", "name"); err != nil {
+				return err
+			}
+			return dropIndexes(ctx, db, "movies", "credits_person_role")
+		},
+	})
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// One season per number and one episode per season and number, plus the content type filter of the listing
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "series_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			err := createIndexes(ctx, db, "seasons",
+				mongo.IndexModel{Keys: bson.D{{Key: "series_id", Value: 1}, {Key: "season_number", Value: 1}}, Options: named("series_season_unique").SetUnique(true)},
+			)
+			if err != nil {
+				return err
+			}
+
+			err = createIndexes(ctx, db, "episodes",
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "series_id", Value: 1}, {Key: "season_number", Value: 1}, {Key: "episode_number", Value: 1}},
+					Options: named("series_season_episode_unique").SetUnique(true),
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			return createIndexes(ctx, db, "movies",
+				mongo.IndexModel{Keys: bson.D{{Key: "type", Value: 1}}, Options: named("type")},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexes(ctx, db, "seasons", "series_season_unique"); err != nil {
+				return err
+			}
+			if err := dropIndexes(ctx, db, "episodes", "series_season_episode_unique"); err != nil {
+				return err
+			}
+			return dropIndexes(ctx, db, "movies", "type")
+		},
+	})
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Unique collection ids, title listing and the index used to find the collections of a movie
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "collection_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "collections",
+				mongo.IndexModel{Keys: bson.D{{Key: "collection_id", Value: 1}}, Options: named("collection_id_unique").SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "title", Value: 1}}, Options: named("title")},
+				mongo.IndexModel{Keys: bson.D{{Key: "movie_ids", Value: 1}}, Options: named("movie_ids")},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "collections", "collection_id_unique", "title", "movie_ids")
+		},
+	})
+}
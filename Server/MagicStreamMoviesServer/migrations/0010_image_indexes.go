@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// One image record per movie, kind and content hash, listed newest first
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "image_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "images",
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "imdb_id", Value: 1}, {Key: "kind", Value: 1}, {Key: "hash", Value: 1}},
+					Options: named("imdb_id_kind_hash_unique").SetUnique(true),
+				},
+				mongo.IndexModel{Keys: bson.D{{Key: "imdb_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: named("imdb_id_created_at")},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "images", "imdb_id_kind_hash_unique", "imdb_id_created_at")
+		},
+	})
+}
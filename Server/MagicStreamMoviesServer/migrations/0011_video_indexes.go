@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// One video per movie
+func init() {
+	register(Migration{
+		Version: 11,
+		Name:    "video_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "videos",
+				mongo.IndexModel{Keys: bson.D{{Key: "imdb_id", Value: 1}}, Options: named("imdb_id_unique").SetUnique(true)},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "videos", "imdb_id_unique")
+		},
+	})
+}
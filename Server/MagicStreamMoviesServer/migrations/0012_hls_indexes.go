@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// One HLS package per movie
+func init() {
+	register(Migration{
+		Version: 12,
+		Name:    "hls_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "hls_packages",
+				mongo.IndexModel{Keys: bson.D{{Key: "imdb_id", Value: 1}}, Options: named("imdb_id_unique").SetUnique(true)},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "hls_packages", "imdb_id_unique")
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// One progress document per user and movie, and the continue watching listing
+func init() {
+	register(Migration{
+		Version: 13,
+		Name:    "watch_progress_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "watch_progress",
+				mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "imdb_id", Value: 1}}, Options: named("user_id_imdb_id_unique").SetUnique(true)},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "completed", Value: 1}, {Key: "updated_at", Value: -1}},
+					Options: named("user_id_completed_updated_at"),
+				},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "watch_progress", "user_id_imdb_id_unique", "user_id_completed_updated_at")
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Watch history timeline of a user and the lookup of the last entry of a movie when recording events
+func init() {
+	register(Migration{
+		Version: 14,
+		Name:    "history_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "history",
+				mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_at", Value: -1}, {Key: "_id", Value: -1}}, Options: named("user_id_last_at")},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "imdb_id", Value: 1}, {Key: "event", Value: 1}, {Key: "last_at", Value: -1}},
+					Options: named("user_id_imdb_id_event_last_at"),
+				},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "history", "user_id_last_at", "user_id_imdb_id_event_last_at")
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// A movie is on the watchlist of a user at most once, listed in the order it was added
+func init() {
+	register(Migration{
+		Version: 15,
+		Name:    "watchlist_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "watchlist",
+				mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "imdb_id", Value: 1}}, Options: named("user_id_imdb_id_unique").SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "added_at", Value: 1}}, Options: named("user_id_added_at")},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "watchlist", "user_id_imdb_id_unique", "user_id_added_at")
+		},
+	})
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// One rating per user and movie
+func init() {
+	register(Migration{
+		Version: 16,
+		Name:    "rating_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "ratings",
+				mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "imdb_id", Value: 1}}, Options: named("user_id_imdb_id_unique").SetUnique(true)},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "ratings", "user_id_imdb_id_unique")
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Approved reviews of a movie are listed newest first, the moderation queue lists reviews of one status oldest first
+func init() {
+	register(Migration{
+		Version: 17,
+		Name:    "review_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, "reviews",
+				mongo.IndexModel{Keys: bson.D{{Key: "imdb_id", Value: 1}, {Key: "status", Value: 1}, {Key: "_id", Value: -1}}, Options: named("imdb_id_status_id")},
+				mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "_id", Value: 1}}, Options: named("status_id")},
+			)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, "reviews", "imdb_id_status_id", "status_id")
+		},
+	})
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Removes the excluded_from_ai flag copied onto movies by AddMovie and imports, it only belongs to rankings collection
+func init() {
+	register(Migration{
+		Version: 19,
+		Name:    "movie_ranking_flag_cleanup",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("movies").UpdateMany(ctx,
+				bson.M{"ranking.excluded_from_ai": bson.M{"$exists": true}},
+				bson.M{"$unset": bson.M{"ranking.excluded_from_ai": ""}})
+			return err
+		},
+		//The removed flag was never read from movies, there is nothing to restore
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return nil
+		},
+	})
+}
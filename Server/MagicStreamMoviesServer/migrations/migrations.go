@@ -0,0 +1,217 @@
+// Package migrations holds the versioned changes applied to the database (indexes, data fixes...).
+// Applied versions are tracked on the schema_migrations collection so every migration runs only once
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Collection that stores one document per applied migration
+const migrationsCollection = "schema_migrations"
+
+// Migration is a versioned change of the database with the code to apply and revert it
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+	Down    func(ctx context.Context, db *mongo.Database) error
+}
+
+// Document stored on schema_migrations for every applied migration
+type appliedMigration struct {
+	Version   int       `bson:"version"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// Status of a migration, returned by the status command
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Every migration of the application. Each file of this package registers its own migration
+var registry []Migration
+
+func register(migration Migration) {
+	registry = append(registry, migration)
+}
+
+// Function that returns every migration sorted by version
+func all() []Migration {
+	migrations := append([]Migration(nil), registry...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
+
+// Function that returns the applied migrations indexed by version
+func applied(ctx context.Context, db *mongo.Database) (map[int]appliedMigration, error) {
+	cursor, err := db.Collection(migrationsCollection).Find(ctx, bson.M{})
+
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var migrations []appliedMigration
+	if err := cursor.All(ctx, &migrations); err != nil {
+		return nil, err
+	}
+
+	result := map[int]appliedMigration{}
+	for _, migration := range migrations {
+		result[migration.Version] = migration
+	}
+
+	return result, nil
+}
+
+// Function that returns the status of every migration sorted by version
+func GetStatus(ctx context.Context, client *mongo.Client) ([]Status, error) {
+	db := database.OpenDatabase(client)
+
+	done, err := applied(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range all() {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := done[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Function that applies every pending migration up to version target (0 applies all of them) and returns the applied ones
+func Up(ctx context.Context, client *mongo.Client, target int) ([]Migration, error) {
+	db := database.OpenDatabase(client)
+
+	done, err := applied(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, migration := range all() {
+		if target > 0 && migration.Version > target {
+			break
+		}
+
+		if _, ok := done[migration.Version]; ok {
+			continue
+		}
+
+		if err := migration.Up(ctx, db); err != nil {
+			return ran, fmt.Errorf("migration %d %s failed: %w", migration.Version, migration.Name, err)
+		}
+
+		record := appliedMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+		if _, err := db.Collection(migrationsCollection).InsertOne(ctx, record); err != nil {
+			return ran, err
+		}
+
+		ran = append(ran, migration)
+	}
+
+	return ran, nil
+}
+
+// Function that reverts the last steps applied migrations, newest first, and returns the reverted ones
+func Down(ctx context.Context, client *mongo.Client, steps int) ([]Migration, error) {
+	db := database.OpenDatabase(client)
+
+	done, err := applied(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := all()
+
+	var reverted []Migration
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		migration := migrations[i]
+
+		if _, ok := done[migration.Version]; !ok {
+			continue
+		}
+
+		if err := migration.Down(ctx, db); err != nil {
+			return reverted, fmt.Errorf("reverting migration %d %s failed: %w", migration.Version, migration.Name, err)
+		}
+
+		if _, err := db.Collection(migrationsCollection).DeleteOne(ctx, bson.M{"version": migration.Version}); err != nil {
+			return reverted, err
+		}
+
+		reverted = append(reverted, migration)
+	}
+
+	return reverted, nil
+}
+
+// Function that returns the migrations that were not applied yet
+func Pending(ctx context.Context, client *mongo.Client) ([]Migration, error) {
+	done, err := applied(ctx, database.OpenDatabase(client))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range all() {
+		if _, ok := done[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Function that creates indexes on a collection, used by Up functions
+func createIndexes(ctx context.Context, db *mongo.Database, collection string, indexes ...mongo.IndexModel) error {
+	_, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// Function that drops indexes by name, used by Down functions. Indexes that do not exist are ignored
+func dropIndexes(ctx context.Context, db *mongo.Database, collection string, names ...string) error {
+	for _, name := range names {
+		err := db.Collection(collection).Indexes().DropOne(ctx, name)
+
+		var commandErr mongo.CommandError
+		if errors.As(err, &commandErr) && commandErr.Name == "IndexNotFound" {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Function that returns index options with a name, all indexes are named so Down functions can drop them
+func named(name string) *options.IndexOptionsBuilder {
+	return options.Index().SetName(name)
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Kinds of images stored for a movie
+const ImageKindPoster = "poster"
+const ImageKindBackdrop = "backdrop"
+
+// Image uploaded for a movie and kept on the media blob store. Hash is the SHA-256 of the original file, it is part of
+// every URL so the files never change and can be cached forever
+type ImageAsset struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ImdbID      string        `bson:"imdb_id" json:"imdb_id"`
+	Kind        string        `bson:"kind" json:"kind" validate:"required,oneof=poster backdrop"`
+	Hash        string        `bson:"hash" json:"hash"`
+	ContentType string        `bson:"content_type" json:"content_type"`
+	Width       int           `bson:"width" json:"width"`
+	Height      int           `bson:"height" json:"height"`
+	Size        int64         `bson:"size" json:"size"`
+	Key         string        `bson:"key" json:"-"` //Key of the original file on the blob store
+	URL         string        `bson:"url" json:"url"`
+	Thumbnails  []Thumbnail   `bson:"thumbnails" json:"thumbnails"`
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+}
+
+// Resized copy of an image
+type Thumbnail struct {
+	Width  int    `bson:"width" json:"width"`
+	Height int    `bson:"height" json:"height"`
+	Key    string `bson:"key" json:"-"`
+	URL    string `bson:"url" json:"url"`
+}
+
+// Video file of a movie kept on the media blob store and played through the streaming routes
+type VideoAsset struct {
+	ID           bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ImdbID       string        `bson:"imdb_id" json:"imdb_id"` //Every movie has at most one video
+	Key          string        `bson:"key" json:"-"`           //Key of the file on the blob store
+	ContentType  string        `bson:"content_type" json:"content_type"`
+	Size         int64         `bson:"size" json:"size"`
+	OriginalName string        `bson:"original_name" json:"original_name"` //Name of the uploaded file
+	CreatedAt    time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// Pre-segmented HLS renditions of a movie stored below Directory on the blob store, one subdirectory per rendition
+type HLSPackage struct {
+	ID              bson.ObjectID  `bson:"_id,omitempty" json:"_id"`
+	ImdbID          string         `bson:"imdb_id" json:"imdb_id"`
+	Directory       string         `bson:"directory" json:"directory"`
+	SegmentDuration float64        `bson:"segment_duration" json:"segment_duration"`
+	Renditions      []HLSRendition `bson:"renditions" json:"renditions"`
+	CreatedAt       time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+// One quality of an HLS package
+type HLSRendition struct {
+	Name        string   `bson:"name" json:"name"` //Subdirectory holding the segments, also used on the playlist URLs
+	Bandwidth   int      `bson:"bandwidth" json:"bandwidth"`
+	Resolution  string   `bson:"resolution,omitempty" json:"resolution,omitempty"`
+	Codecs      string   `bson:"codecs,omitempty" json:"codecs,omitempty"`
+	InitSegment string   `bson:"init_segment,omitempty" json:"init_segment,omitempty"` //fMP4 initialization segment
+	Segments    []string `bson:"segments" json:"segments"`                             //Segment file names in playing order
+	//Seconds of every segment, same order as Segments. Empty when every segment lasts the package SegmentDuration
+	SegmentDurations []float64 `bson:"segment_durations,omitempty" json:"segment_durations,omitempty"`
+}
+
+// Request registering a directory of pre-segmented renditions
+type HLSRegistration struct {
+	Directory       string                     `json:"directory" validate:"required,max=500"`            //Blob store prefix, for example hls/tt0111161
+	SegmentDuration float64                    `json:"segment_duration" validate:"required,gt=0,lte=60"` //Seconds of segments without their own duration
+	Renditions      []HLSRenditionRegistration `json:"renditions" validate:"required,min=1,dive"`
+}
+
+type HLSRenditionRegistration struct {
+	Name       string `json:"name" validate:"required,alphanum,max=50"`
+	Bandwidth  int    `json:"bandwidth" validate:"required,min=1"`    //Peak bits per second
+	Resolution string `json:"resolution" validate:"omitempty,max=20"` //WIDTHxHEIGHT, for example 1280x720
+	Codecs     string `json:"codecs" validate:"omitempty,max=200"`    //RFC 6381 codecs, for example avc1.64001f,mp4a.40.2
+	//Seconds of every segment in playing order. When empty they are read from an index.m3u8 uploaded with the segments
+	SegmentDurations []float64 `json:"segment_durations" validate:"omitempty,max=100000,dive,gt=0,lte=60"`
+}
+
+// Signed URLs a player uses to play a movie. Empty URLs mean the movie has no file of that kind
+type Playback struct {
+	ImdbID           string    `json:"imdb_id"`
+	VideoURL         string    `json:"video_url,omitempty"`
+	VideoContentType string    `json:"video_content_type,omitempty"`
+	HLSURL           string    `json:"hls_url,omitempty"`
+	YoutubeID        string    `json:"youtube_id,omitempty"` //Trailer, played when there is no hosted video
+	ExpiresAt        time.Time `json:"expires_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Collection is an ordered group of movies such as a trilogy or a franchise phase
+type Collection struct {
+	ID           bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	CollectionID string        `bson:"collection_id" json:"collection_id"` //Unique identifier used on routes
+	Title        string        `bson:"title" json:"title" validate:"required,min=2,max=500"`
+	Description  string        `bson:"description,omitempty" json:"description,omitempty" validate:"omitempty,max=5000"`
+	ArtworkPath  string        `bson:"artwork_path,omitempty" json:"artwork_path,omitempty" validate:"omitempty,url"`
+	MovieIDs     []string      `bson:"movie_ids" json:"movie_ids" validate:"required,unique,dive,required"` //imdb_id of every entry in watching order
+	CreatedAt    time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// Fields an admin can change on a collection. Nil fields are left untouched
+type CollectionUpdate struct {
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	ArtworkPath *string   `json:"artwork_path"`
+	MovieIDs    *[]string `json:"movie_ids"`
+}
+
+// Collection together with its movies in watching order. Archived movies are left out
+type CollectionDetails struct {
+	Collection Collection `json:"collection"`
+	Movies     []Movie    `json:"movies"`
+}
+
+// Movie of a collection, as referenced by the previous and next entries of another movie
+type CollectionEntry struct {
+	ImdbID     string `json:"imdb_id"`
+	Title      string `json:"title"`
+	PosterPath string `json:"poster_path"`
+}
+
+// Position of a movie inside a collection together with its neighbours
+type CollectionMembership struct {
+	CollectionID string           `json:"collection_id"`
+	Title        string           `json:"title"`
+	ArtworkPath  string           `json:"artwork_path,omitempty"`
+	Position     int              `json:"position"` //1 based position among the movies that are not archived
+	Total        int              `json:"total"`
+	Previous     *CollectionEntry `json:"previous,omitempty"`
+	Next         *CollectionEntry `json:"next,omitempty"`
+}
+
+// Movie as returned by GetMovie, with the collections it belongs to
+type MovieDetails struct {
+	Movie       `bson:",inline"`
+	Collections []CollectionMembership `bson:"-" json:"collections"`
+}
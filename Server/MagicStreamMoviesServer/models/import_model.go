@@ -0,0 +1,18 @@
+package models
+
+// Outcome of a single row of a catalog import
+type ImportRowResult struct {
+	Row    int    `json:"row"`               //1 based position of the row in the file (header excluded)
+	ImdbID string `json:"imdb_id,omitempty"` //Empty when the row could not be parsed
+	Reason string `json:"reason,omitempty"`  //Why the row was skipped or failed
+}
+
+// Report returned after importing a catalog file. On dry runs nothing is written but rows are classified the same way
+type ImportReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Total    int               `json:"total"`
+	Inserted []ImportRowResult `json:"inserted"`
+	Updated  []ImportRowResult `json:"updated"`
+	Skipped  []ImportRowResult `json:"skipped"`
+	Failed   []ImportRowResult `json:"failed"`
+}
@@ -11,6 +11,8 @@ In Go, a package is a fundamental unit for organizing and structuring code.
 package models
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
@@ -20,19 +22,74 @@ type Genre struct {
 }
 
 type Ranking struct {
-	RankingValue int    `bson:"ranking_value" json:"ranking_value" validate:"required"`
-	RankingName  string `bson:"ranking_name" json:"ranking_name" validate:"required"`
+	RankingValue   int    `bson:"ranking_value" json:"ranking_value" validate:"required"`
+	RankingName    string `bson:"ranking_name" json:"ranking_name" validate:"required"`
+	ExcludedFromAI bool   `bson:"excluded_from_ai,omitempty" json:"excluded_from_ai,omitempty"` //When true the LLM never picks this ranking (for example Not_Ranked)
+}
+
+// Fields an admin can change on a ranking. Nil fields are left untouched
+type RankingUpdate struct {
+	RankingValue   *int    `json:"ranking_value"`
+	RankingName    *string `json:"ranking_name"`
+	ExcludedFromAI *bool   `json:"excluded_from_ai"`
 }
 
 type Movie struct {
-	ID          bson.ObjectID `bson:"_id,omitempty" json:"_id"`                             //Unique identifier for movie document on DB
-	ImdbID      string        `bson:"imdb_id" json:"imdb_id" validate:"required"`           //Unique identifier from IMDB for a specific movie, serie, actor.. Validate "required" is used to validate that this parameter os not empty
-	Title       string        `bson:"title" json:"title" validate:"required,min=2,max=500"` //Required is to check that the parameter is not empty and min 2 it to check that the title is at least 2 characters
-	PosterPath  string        `bson:"poster_path" json:"poster_path" valdiate:"required,url"`
-	YoutubeID   string        `bson:"youtube_id" json:"youtube_id" validate:"required"` //Youtube URL of movie trailer
-	Genre       []Genre       `bson:"genre" json:"genre" validate:"required,dive"`      //Movie can have one or more genre
-	AdminReview string        `bson:"admin_review" json:"admin_review"`                 //Review of the movie
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"_id"`                               //Unique identifier for movie document on DB
+	ImdbID      string        `bson:"imdb_id" json:"imdb_id" validate:"required"`             //Unique identifier from IMDB for a specific movie, serie, actor.. Validate "required" is used to validate that this parameter os not empty
+	Title       string        `bson:"title" json:"title" validate:"required,min=2,max=500"`   //Required is to check that the parameter is not empty and min 2 it to check that the title is at least 2 characters
+	PosterPath  string        `bson:"poster_path" json:"poster_path" validate:"required,url"` //Poster image, uploaded posters point to the media asset store
+	YoutubeID   string        `bson:"youtube_id" json:"youtube_id" validate:"required"`       //Youtube URL of movie trailer
+	Genre       []Genre       `bson:"genre" json:"genre" validate:"required,dive"`            //Movie can have one or more genre
+	AdminReview string        `bson:"admin_review" json:"admin_review"`                       //Review of the movie
 	Ranking     Ranking       `bson:"ranking" json:"ranking" validate:"required"`
+	Archived    bool          `bson:"archived" json:"archived"`                                                     //Soft deleted movies are hidden from every listing
+	ArchivedAt  *time.Time    `bson:"archived_at,omitempty" json:"archived_at,omitempty"`                           //When the movie was soft deleted
+	Type        string        `bson:"type,omitempty" json:"type,omitempty" validate:"omitempty,oneof=movie series"` //series for TV series, empty or movie for films
+
+	//Optional metadata. Documents created before these fields existed simply omit them
+	ReleaseDate      *time.Time `bson:"release_date,omitempty" json:"release_date,omitempty"`
+	BackdropPath     string     `bson:"backdrop_path,omitempty" json:"backdrop_path,omitempty" validate:"omitempty,url"` //Wide background image
+	RuntimeMinutes   int        `bson:"runtime_minutes,omitempty" json:"runtime_minutes,omitempty" validate:"omitempty,min=1,max=1000"`
+	Synopsis         string     `bson:"synopsis,omitempty" json:"synopsis,omitempty" validate:"omitempty,max=5000"`
+	OriginalLanguage string     `bson:"original_language,omitempty" json:"original_language,omitempty" validate:"omitempty,bcp47_language_tag"` //Language tag such as en or es
+	SpokenLanguages  []string   `bson:"spoken_languages,omitempty" json:"spoken_languages,omitempty" validate:"omitempty,dive,bcp47_language_tag"`
+	ContentRating    string     `bson:"content_rating,omitempty" json:"content_rating,omitempty" validate:"omitempty,oneof=G PG PG-13 R NC-17 NR"` //MPA rating, NR is not rated
+	Directors        []string   `bson:"directors,omitempty" json:"directors,omitempty" validate:"omitempty,dive,min=2,max=200"`
+	Cast             []string   `bson:"cast,omitempty" json:"cast,omitempty" validate:"omitempty,dive,min=2,max=200"`
+
+	//People of cast and crew. Directors and Cast names are filled from these credits when they are given
+	Credits []Credit `bson:"credits,omitempty" json:"credits,omitempty" validate:"omitempty,dive"`
+
+	//User star ratings, updated incrementally every time a rating is added, changed or deleted
+	RatingSum     int     `bson:"rating_sum,omitempty" json:"-"`
+	RatingCount   int     `bson:"rating_count,omitempty" json:"rating_count"`
+	RatingAverage float64 `bson:"rating_average,omitempty" json:"rating_average"` //Average stars rounded to 2 decimals
+
+	//Per user fields computed on every response, never stored
+	InWatchlist bool `bson:"-" json:"in_watchlist,omitempty"` //Movie is on the watchlist of the authenticated user
+	MyRating    int  `bson:"-" json:"my_rating,omitempty"`    //Stars given by the authenticated user
+}
+
+// Fields an admin can change on a movie. Nil fields are left untouched
+type MovieUpdate struct {
+	Title        *string  `json:"title"`
+	PosterPath   *string  `json:"poster_path"`
+	BackdropPath *string  `json:"backdrop_path"`
+	YoutubeID    *string  `json:"youtube_id"`
+	Genre        *[]Genre `json:"genre"`
+	Ranking      *Ranking `json:"ranking"`
+	Type         *string  `json:"type"`
+
+	ReleaseDate      *time.Time `json:"release_date"`
+	RuntimeMinutes   *int       `json:"runtime_minutes"`
+	Synopsis         *string    `json:"synopsis"`
+	OriginalLanguage *string    `json:"original_language"`
+	SpokenLanguages  *[]string  `json:"spoken_languages"`
+	ContentRating    *string    `json:"content_rating"`
+	Directors        *[]string  `json:"directors"`
+	Cast             *[]string  `json:"cast"`
+	Credits          *[]Credit  `json:"credits"`
 }
 
 /*
@@ -42,3 +99,64 @@ client code.
 
 So we can define in our structs how the fields map to our MongoDB as well as to the JSON data will be sent to calling client code
 */
+
+// Filters shared by the movies listing and the movies search
+type MovieFilters struct {
+	Genre         string `form:"genre" validate:"omitempty,min=2,max=100"`                                       //Genre name the movie must have
+	RankingValue  *int   `form:"ranking_value" validate:"omitempty"`                                             //Exact ranking value
+	RankingName   string `form:"ranking_name" validate:"omitempty,max=100"`                                      //Exact ranking name
+	Title         string `form:"title" validate:"omitempty,max=500"`                                             //Case sensitive title prefix
+	YearFrom      int    `form:"year_from" validate:"omitempty,min=1870,max=3000"`                               //Released on or after this year
+	YearTo        int    `form:"year_to" validate:"omitempty,min=1870,max=3000,gtefield=YearFrom"`               //Released on or before this year
+	RuntimeMin    int    `form:"runtime_min" validate:"omitempty,min=1"`                                         //Minimum runtime in minutes
+	RuntimeMax    int    `form:"runtime_max" validate:"omitempty,min=1,gtefield=RuntimeMin"`                     //Maximum runtime in minutes
+	Language      string `form:"language" validate:"omitempty,bcp47_language_tag"`                               //Original or spoken language
+	ContentRating string `form:"content_rating" validate:"omitempty,oneof=G PG PG-13 R NC-17 NR"`                //Exact content rating
+	Director      string `form:"director" validate:"omitempty,max=200"`                                          //Exact director name
+	Cast          string `form:"cast" validate:"omitempty,max=200"`                                              //Exact cast member name
+	Type          string `form:"type" validate:"omitempty,oneof=movie series"`                                   //Only films or only series
+	Person        string `form:"person" validate:"omitempty,max=100"`                                            //person_id credited on the movie
+	PersonRole    string `form:"person_role" validate:"omitempty,oneof=director actor writer producer composer"` //Role of person on the movie
+}
+
+// Query parameters accepted by the movies listing. Validated with the same go playground validator as the models
+type MovieQuery struct {
+	MovieFilters
+	Sort   string `form:"sort" validate:"omitempty,oneof=title ranking created release"` //Sort field, created is insertion date
+	Order  string `form:"order" validate:"omitempty,oneof=asc desc"`                     //Sort direction
+	Limit  int64  `form:"limit" validate:"omitempty,min=1,max=100"`                      //Page size
+	Cursor string `form:"cursor"`                                                        //Opaque cursor returned as next_cursor or prev_cursor
+}
+
+// Pagination parameters of listings without filters or sort options
+type PageQuery struct {
+	Limit  int64  `form:"limit" validate:"omitempty,min=1,max=100"` //Page size
+	Cursor string `form:"cursor"`                                   //Opaque cursor returned as next_cursor or prev_cursor
+}
+
+// Page of documents returned by every paginated endpoint
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`                 //Amount of documents matching the filters on every page
+	NextCursor string `json:"next_cursor,omitempty"` //Cursor to request the following page, empty on the last page
+	PrevCursor string `json:"prev_cursor,omitempty"` //Cursor to request the previous page, empty on the first page
+}
+
+// Query parameters accepted by the movies search
+type MovieSearchQuery struct {
+	MovieFilters
+	Q      string `form:"q" validate:"required,min=2,max=200"` //Search terms
+	Limit  int64  `form:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `form:"cursor"`
+}
+
+// Movie returned by the search together with how relevant it is for the search terms
+type MovieSearchResult struct {
+	Movie `bson:",inline"`
+	Score float64 `bson:"score" json:"score"` //Text score, or share of matched trigrams when the typo tolerant fallback was used
+}
+
+// Body of a genre rename
+type GenreRename struct {
+	GenreName string `json:"genre_name" validate:"required,min=2,max=100"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Person is an actor, director or any other member of cast and crew stored on people collection
+type Person struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	PersonID    string        `bson:"person_id" json:"person_id"` //Unique identifier referenced by movie credits
+	Name        string        `bson:"name" json:"name" validate:"required,min=2,max=200"`
+	Biography   string        `bson:"biography,omitempty" json:"biography,omitempty" validate:"omitempty,max=10000"`
+	BirthDate   *time.Time    `bson:"birth_date,omitempty" json:"birth_date,omitempty"`
+	ProfilePath string        `bson:"profile_path,omitempty" json:"profile_path,omitempty" validate:"omitempty,url"` //Photo of the person
+	ImdbID      string        `bson:"imdb_id,omitempty" json:"imdb_id,omitempty"`                                    //IMDB name identifier (nm...)
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// Fields an admin can change on a person. Nil fields are left untouched
+type PersonUpdate struct {
+	Name        *string    `json:"name"`
+	Biography   *string    `json:"biography"`
+	BirthDate   *time.Time `json:"birth_date"`
+	ProfilePath *string    `json:"profile_path"`
+	ImdbID      *string    `json:"imdb_id"`
+}
+
+// Credit links a movie with a person of its cast and crew
+type Credit struct {
+	PersonID  string `bson:"person_id" json:"person_id" validate:"required"`
+	Role      string `bson:"role" json:"role" validate:"required,oneof=director actor writer producer composer"`
+	Character string `bson:"character,omitempty" json:"character,omitempty" validate:"omitempty,max=200"` //Only for actors
+	Order     int    `bson:"order" json:"order" validate:"min=0"`                                         //Billing order, lower first
+}
+
+// Movie a person took part in, as returned on the person filmography
+type FilmographyEntry struct {
+	ImdbID      string     `json:"imdb_id"`
+	Title       string     `json:"title"`
+	PosterPath  string     `json:"poster_path"`
+	ReleaseDate *time.Time `json:"release_date,omitempty"`
+	Role        string     `json:"role"`
+	Character   string     `json:"character,omitempty"`
+	Order       int        `json:"order"`
+}
+
+// Person together with every movie they took part in
+type PersonDetails struct {
+	Person      Person             `json:"person"`
+	Filmography []FilmographyEntry `json:"filmography"`
+}
+
+// Movie sharing cast or crew with another movie
+type RelatedMovie struct {
+	Movie        `bson:",inline"`
+	SharedPeople int `bson:"shared_people" json:"shared_people"` //Amount of people credited on both movies
+}
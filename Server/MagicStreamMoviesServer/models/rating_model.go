@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Stars a user gave to a movie, stored on ratings collection
+type Rating struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID    string        `bson:"user_id" json:"user_id"`
+	ImdbID    string        `bson:"imdb_id" json:"imdb_id"`
+	Stars     int           `bson:"stars" json:"stars"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// Body of a rating request
+type RatingInput struct {
+	Stars int `json:"stars" validate:"required,min=1,max=5"`
+}
+
+// Rating aggregates of a movie returned after a rating changes
+type RatingSummary struct {
+	ImdbID        string  `json:"imdb_id"`
+	RatingCount   int     `json:"rating_count"`
+	RatingAverage float64 `json:"rating_average"`
+	MyRating      int     `json:"my_rating,omitempty"`
+}
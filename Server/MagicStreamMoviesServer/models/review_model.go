@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Moderation status of a review. Only approved reviews are listed on movies
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+	ReviewHidden   = "hidden"
+)
+
+// Review written by a user about a movie, stored on reviews collection
+type Review struct {
+	ID                  bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ImdbID              string        `bson:"imdb_id" json:"imdb_id"`
+	UserID              string        `bson:"user_id" json:"user_id"`
+	Text                string        `bson:"text" json:"text"`
+	Ranking             *Ranking      `bson:"ranking,omitempty" json:"ranking,omitempty"`                           //Ranking chosen by the classifier, missing when classification failed
+	RankingConfidence   float64       `bson:"ranking_confidence,omitempty" json:"ranking_confidence,omitempty"`     //How sure the classifier is about the ranking, from 0 to 1
+	RankingRationale    string        `bson:"ranking_rationale,omitempty" json:"ranking_rationale,omitempty"`       //Why the classifier chose the ranking
+	ClassificationError string        `bson:"classification_error,omitempty" json:"classification_error,omitempty"` //Why the classifier could not rank the review
+	Status              string        `bson:"status" json:"status"`
+	HelpfulUp           int           `bson:"helpful_up" json:"helpful_up"`                         //Users that found the review helpful
+	HelpfulDown         int           `bson:"helpful_down" json:"helpful_down"`                     //Users that did not
+	HelpfulScore        int           `bson:"helpful_score" json:"helpful_score"`                   //helpful_up minus helpful_down, most helpful reviews sort first by it
+	ModeratedBy         string        `bson:"moderated_by,omitempty" json:"moderated_by,omitempty"` //Admin that changed the status last
+	ModeratedAt         *time.Time    `bson:"moderated_at,omitempty" json:"moderated_at,omitempty"`
+	CreatedAt           time.Time     `bson:"created_at" json:"created_at"`
+
+	//Per user field computed on every response, never stored
+	MyVote string `bson:"-" json:"my_vote,omitempty"`
+}
+
+// Body of a new review
+type ReviewInput struct {
+	Text string `json:"text" validate:"required,min=2,max=5000"`
+}
+
+// Sort and pagination parameters of the reviews of a movie
+type ReviewListQuery struct {
+	PageQuery
+	Sort string `form:"sort" validate:"omitempty,oneof=newest helpful ranking_high ranking_low"` //newest when empty
+}
+
+// Helpful vote values
+const (
+	VoteUp   = "up"
+	VoteDown = "down"
+)
+
+// Helpful vote a user gave to a review, stored on review_votes collection
+type ReviewVote struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	ReviewID  bson.ObjectID `bson:"review_id" json:"review_id"`
+	UserID    string        `bson:"user_id" json:"user_id"`
+	Vote      string        `bson:"vote" json:"vote"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// Body of a helpful vote
+type ReviewVoteInput struct {
+	Vote string `json:"vote" validate:"required,oneof=up down"`
+}
+
+// Filters and pagination parameters of the moderation queue
+type ReviewQueueQuery struct {
+	PageQuery
+	Status string `form:"status" validate:"omitempty,oneof=pending approved rejected hidden"` //pending when empty
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Content types stored on movies collection. Documents without type are movies
+const ContentTypeMovie = "movie"
+const ContentTypeSeries = "series"
+
+// Season of a series. The series itself is a document of movies collection with type series so it shares genres,
+// rankings, admin reviews, search and recommendations with films
+type Season struct {
+	ID           bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	SeriesID     string        `bson:"series_id" json:"series_id"` //imdb_id of the series
+	SeasonNumber int           `bson:"season_number" json:"season_number" validate:"required,min=1"`
+	Title        string        `bson:"title,omitempty" json:"title,omitempty" validate:"omitempty,max=500"`
+	Synopsis     string        `bson:"synopsis,omitempty" json:"synopsis,omitempty" validate:"omitempty,max=5000"`
+	PosterPath   string        `bson:"poster_path,omitempty" json:"poster_path,omitempty" validate:"omitempty,url"`
+	AirDate      *time.Time    `bson:"air_date,omitempty" json:"air_date,omitempty"`
+	EpisodeCount int           `bson:"-" json:"episode_count"` //Computed when listing seasons
+}
+
+// Episode of a season of a series
+type Episode struct {
+	ID             bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	SeriesID       string        `bson:"series_id" json:"series_id"` //imdb_id of the series
+	SeasonNumber   int           `bson:"season_number" json:"season_number" validate:"required,min=1"`
+	EpisodeNumber  int           `bson:"episode_number" json:"episode_number" validate:"required,min=1"`
+	ImdbID         string        `bson:"imdb_id,omitempty" json:"imdb_id,omitempty"` //IMDB identifier of the episode itself
+	Title          string        `bson:"title" json:"title" validate:"required,min=1,max=500"`
+	Synopsis       string        `bson:"synopsis,omitempty" json:"synopsis,omitempty" validate:"omitempty,max=5000"`
+	RuntimeMinutes int           `bson:"runtime_minutes,omitempty" json:"runtime_minutes,omitempty" validate:"omitempty,min=1,max=1000"`
+	AirDate        *time.Time    `bson:"air_date,omitempty" json:"air_date,omitempty"`
+	StillPath      string        `bson:"still_path,omitempty" json:"still_path,omitempty" validate:"omitempty,url"` //Image of the episode
+	YoutubeID      string        `bson:"youtube_id,omitempty" json:"youtube_id,omitempty"`
+}
+
+// Series together with its seasons
+type SeriesSeasons struct {
+	Series  Movie    `json:"series"`
+	Seasons []Season `json:"seasons"`
+}
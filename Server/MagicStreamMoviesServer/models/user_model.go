@@ -19,6 +19,7 @@ type User struct {
 	Token           string        `json:"token" bson:"token"`
 	RefreshToken    string        `json:"refresh_token" bson:"refresh_token"`
 	FavouriteGenres []Genre       `json:"favourite_genres" bson:"favourite_genres" validate:"required,dive"`
+	HistoryPaused   bool          `json:"history_paused" bson:"history_paused"` //When true nothing is added to the watch history
 }
 
 /*
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Last playback position a user reached on a movie, stored on watch_progress collection
+type WatchProgress struct {
+	ID              bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID          string        `bson:"user_id" json:"user_id"`
+	ImdbID          string        `bson:"imdb_id" json:"imdb_id"`
+	PositionSeconds int           `bson:"position_seconds" json:"position_seconds"`
+	DurationSeconds int           `bson:"duration_seconds" json:"duration_seconds"`
+	Completed       bool          `bson:"completed" json:"completed"`                           //Position went past the completed threshold
+	CompletedAt     *time.Time    `bson:"completed_at,omitempty" json:"completed_at,omitempty"` //Last time the movie was completed
+	CreatedAt       time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// Playback position reported by the player at intervals
+type ProgressReport struct {
+	PositionSeconds int `json:"position_seconds" validate:"min=0,ltefield=DurationSeconds"`
+	DurationSeconds int `json:"duration_seconds" validate:"required,min=1"`
+}
+
+// Partially watched movie of the continue watching row
+type ContinueWatchingEntry struct {
+	Movie    Movie         `bson:"movie" json:"movie"`
+	Progress WatchProgress `bson:"progress" json:"progress"`
+}
+
+// Events recorded on the watch history
+const HistoryEventViewed = "viewed"   //The movie details were opened
+const HistoryEventWatched = "watched" //The movie was played
+
+// Entry of the watch history of a user. Repeated events of the same movie close in time extend one entry
+type HistoryEntry struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID    string        `bson:"user_id" json:"user_id"`
+	ImdbID    string        `bson:"imdb_id" json:"imdb_id"`
+	Event     string        `bson:"event" json:"event"`
+	StartedAt time.Time     `bson:"started_at" json:"started_at"`
+	LastAt    time.Time     `bson:"last_at" json:"last_at"` //Last event of the entry, the history is sorted by it
+}
+
+// History entry together with its movie
+type HistoryItem struct {
+	HistoryEntry `bson:",inline"`
+	Movie        Movie `bson:"movie" json:"movie"`
+}
+
+// Privacy settings of the watch history
+type HistorySettings struct {
+	Paused *bool `json:"paused" validate:"required"`
+}
+
+// Movie saved on the watchlist of a user
+type WatchlistEntry struct {
+	ID      bson.ObjectID `bson:"_id,omitempty" json:"_id"`
+	UserID  string        `bson:"user_id" json:"user_id"`
+	ImdbID  string        `bson:"imdb_id" json:"imdb_id"`
+	AddedAt time.Time     `bson:"added_at" json:"added_at"`
+}
+
+// Movie of the watchlist listing with the date it was added
+type WatchlistMovie struct {
+	Movie   `bson:",inline"`
+	AddedAt time.Time `bson:"added_to_watchlist_at" json:"added_to_watchlist_at"`
+}
+
+// Pagination and sort parameters of the watchlist
+type WatchlistQuery struct {
+	PageQuery
+	Sort  string `form:"sort" validate:"omitempty,oneof=added ranking"` //added is the order movies were added
+	Order string `form:"order" validate:"omitempty,oneof=asc desc"`
+}
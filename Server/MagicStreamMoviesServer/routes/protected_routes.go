@@ -3,12 +3,14 @@ package routes
 import (
 	controller "github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/controllers"
 	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/middleware"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/sentiment"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/storage"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 // Setup protected routes
-func SetupProtectedRoutes(router *gin.Engine, client *mongo.Client) {
+func SetupProtectedRoutes(router *gin.Engine, client *mongo.Client, store storage.BlobStore, classifier sentiment.Classifier) {
 	//Protect relevant routes (Auth Middleware is a  Gin handler function used to validate incoming access tokens
 	// and grant/prohibt access to protected endpoints)
 	router.Use(middleware.AuthMiddleware())
@@ -18,11 +20,182 @@ func SetupProtectedRoutes(router *gin.Engine, client *mongo.Client) {
 	//Route that returns a single movie from DB given IMDB id
 	router.GET("/movie/:imdb_id", controller.GetMovie(client))
 
+	//Route that partially updates a movie (Admin only)
+	router.PATCH("/movie/:imdb_id", middleware.AdminMiddleware(), controller.UpdateMovie(client))
+
+	//Route that soft deletes a movie, hiding it from every listing (Admin only)
+	router.DELETE("/movie/:imdb_id", middleware.AdminMiddleware(), controller.DeleteMovie(client))
+
+	//Route that restores a soft deleted movie (Admin only)
+	router.POST("/movie/:imdb_id/restore", middleware.AdminMiddleware(), controller.RestoreMovie(client))
+
+	//Route that bulk imports movies from a CSV or NDJSON file (Admin only)
+	router.POST("/admin/movies/import", middleware.AdminMiddleware(), controller.ImportMovies(client))
+
+	//Route that streams the catalog as NDJSON, CSV or JSON-LD (Admin only)
+	router.GET("/admin/movies/export", middleware.AdminMiddleware(), controller.ExportMovies(client))
+
+	//Route that adds a genre (Admin only)
+	router.POST("/admin/genres", middleware.AdminMiddleware(), controller.CreateGenre(client))
+
+	//Route that renames a genre on genres collection and on every movie and user that has it (Admin only)
+	router.PATCH("/admin/genres/:genre_id", middleware.AdminMiddleware(), controller.RenameGenre(client))
+
+	//Route that deletes a genre, genres in use need a replacement_id query parameter (Admin only)
+	router.DELETE("/admin/genres/:genre_id", middleware.AdminMiddleware(), controller.DeleteGenre(client))
+
+	//Route that adds a ranking (Admin only)
+	router.POST("/admin/rankings", middleware.AdminMiddleware(), controller.CreateRanking(client))
+
+	//Route that changes a ranking and the movies holding it (Admin only)
+	router.PATCH("/admin/rankings/:ranking_value", middleware.AdminMiddleware(), controller.UpdateRanking(client))
+
+	//Route that deletes a ranking (Admin only)
+	router.DELETE("/admin/rankings/:ranking_value", middleware.AdminMiddleware(), controller.DeleteRanking(client))
+
+	//Route that ranks again one batch of the movies affected by ranking changes (Admin only)
+	router.POST("/admin/rankings/recompute", middleware.AdminMiddleware(), controller.RecomputeRankings(client, classifier))
+
+	//Route that returns a person with their filmography
+	router.GET("/people/:person_id", controller.GetPerson(client))
+
+	//Route that returns movies sharing cast and crew with a movie
+	router.GET("/movie/:imdb_id/related", controller.GetRelatedMovies(client))
+
+	//Route that adds a person (Admin only)
+	router.POST("/admin/people", middleware.AdminMiddleware(), controller.CreatePerson(client))
+
+	//Route that partially updates a person (Admin only)
+	router.PATCH("/admin/people/:person_id", middleware.AdminMiddleware(), controller.UpdatePerson(client))
+
+	//Route that deletes a person not credited on any movie (Admin only)
+	router.DELETE("/admin/people/:person_id", middleware.AdminMiddleware(), controller.DeletePerson(client))
+
+	//Route that returns a series with its seasons
+	router.GET("/series/:imdb_id/seasons", controller.GetSeasons(client))
+
+	//Route that returns the episodes of a season
+	router.GET("/series/:imdb_id/seasons/:season/episodes", controller.GetSeasonEpisodes(client))
+
+	//Route that returns a single episode
+	router.GET("/series/:imdb_id/seasons/:season/episodes/:episode", controller.GetEpisode(client))
+
+	//Route that returns the episode following the given one
+	router.GET("/series/:imdb_id/seasons/:season/episodes/:episode/next", controller.GetNextEpisode(client))
+
+	//Route that creates or replaces a season (Admin only)
+	router.PUT("/admin/series/:imdb_id/seasons", middleware.AdminMiddleware(), controller.UpsertSeason(client))
+
+	//Route that creates or replaces an episode (Admin only)
+	router.PUT("/admin/series/:imdb_id/episodes", middleware.AdminMiddleware(), controller.UpsertEpisode(client))
+
+	//Route that deletes an episode (Admin only)
+	router.DELETE("/admin/series/:imdb_id/seasons/:season/episodes/:episode", middleware.AdminMiddleware(), controller.DeleteEpisode(client))
+
+	//Route that returns every collection
+	router.GET("/collections", controller.GetCollections(client))
+
+	//Route that returns a collection with its movies in order
+	router.GET("/collections/:collection_id", controller.GetCollection(client))
+
+	//Route that adds a collection (Admin only)
+	router.POST("/admin/collections", middleware.AdminMiddleware(), controller.CreateCollection(client))
+
+	//Route that partially updates a collection (Admin only)
+	router.PATCH("/admin/collections/:collection_id", middleware.AdminMiddleware(), controller.UpdateCollection(client))
+
+	//Route that deletes a collection (Admin only)
+	router.DELETE("/admin/collections/:collection_id", middleware.AdminMiddleware(), controller.DeleteCollection(client))
+
+	//Route that uploads a poster or backdrop with its thumbnails (Admin only)
+	router.POST("/admin/movies/:imdb_id/images", middleware.AdminMiddleware(), controller.UploadMovieImage(client, store))
+
+	//Route that returns the images uploaded for a movie
+	router.GET("/movie/:imdb_id/images", controller.GetMovieImages(client))
+
+	//Route that uploads the video of a movie (Admin only)
+	router.POST("/admin/movies/:imdb_id/video", middleware.AdminMiddleware(), controller.UploadMovieVideo(client, store))
+
+	//Route that streams the video of a movie with range request support
+	router.GET("/stream/:imdb_id", controller.StreamMovie(client, store))
+
+	//Route that registers a directory of pre-segmented HLS renditions of a movie (Admin only)
+	router.PUT("/admin/movies/:imdb_id/hls", middleware.AdminMiddleware(), controller.RegisterHLSPackage(client, store))
+
+	//Route that returns the HLS master playlist of a movie
+	router.GET("/stream/:imdb_id/hls/master.m3u8", controller.GetHLSMasterPlaylist(client))
+
+	//Route that returns the playlist or a segment of an HLS rendition
+	router.GET("/stream/:imdb_id/hls/:rendition/:file", controller.GetHLSFile(client, store))
+
+	//Route that issues signed, expiring URLs to play a movie
+	router.GET("/movie/:imdb_id/playback", controller.GetMoviePlayback(client))
+
+	//Route where the player reports the playback position of the current user
+	router.PUT("/movie/:imdb_id/progress", controller.UpdateWatchProgress(client))
+
+	//Route that returns the playback position of the current user on a movie
+	router.GET("/movie/:imdb_id/progress", controller.GetWatchProgress(client))
+
+	//Route that returns the movies the current user started and did not complete
+	router.GET("/me/continue-watching", controller.GetContinueWatching(client))
+
+	//Route that returns the watch history of the current user
+	router.GET("/me/history", controller.GetHistory(client))
+
+	//Route that deletes one entry of the watch history of the current user
+	router.DELETE("/me/history/:entry_id", controller.DeleteHistoryEntry(client))
+
+	//Route that deletes the whole watch history of the current user
+	router.DELETE("/me/history", controller.ClearHistory(client))
+
+	//Route that pauses or resumes recording the watch history of the current user
+	router.PUT("/me/history/settings", controller.UpdateHistorySettings(client))
+
+	//Route that returns the watchlist of the current user
+	router.GET("/me/watchlist", controller.GetWatchlist(client))
+
+	//Route that adds a movie to the watchlist of the current user
+	router.POST("/me/watchlist/:imdb_id", controller.AddToWatchlist(client))
+
+	//Route that removes a movie from the watchlist of the current user
+	router.DELETE("/me/watchlist/:imdb_id", controller.RemoveFromWatchlist(client))
+
+	//Route that creates or changes the stars the current user gives to a movie
+	router.PUT("/movie/:imdb_id/rating", controller.RateMovie(client))
+
+	//Route that deletes the rating the current user gave to a movie
+	router.DELETE("/movie/:imdb_id/rating", controller.DeleteRating(client))
+
+	//Route that posts a review of the current user, it waits for moderation before being listed
+	router.POST("/movie/:imdb_id/reviews", controller.CreateReview(client, classifier))
+
+	//Route that returns the approved reviews of a movie sorted by date, helpful votes or ranking
+	router.GET("/movie/:imdb_id/reviews", controller.GetMovieReviews(client))
+
+	//Route that records whether the current user found a review helpful
+	router.PUT("/reviews/:review_id/vote", controller.VoteReview(client))
+
+	//Route that removes the helpful vote of the current user on a review
+	router.DELETE("/reviews/:review_id/vote", controller.DeleteReviewVote(client))
+
+	//Route that returns the reviews waiting for moderation or with the given status (Admin only)
+	router.GET("/admin/reviews", middleware.AdminMiddleware(), controller.GetReviewQueue(client))
+
+	//Route that approves a review so it is listed on its movie (Admin only)
+	router.POST("/admin/reviews/:review_id/approve", middleware.AdminMiddleware(), controller.ApproveReview(client))
+
+	//Route that rejects a pending review (Admin only)
+	router.POST("/admin/reviews/:review_id/reject", middleware.AdminMiddleware(), controller.RejectReview(client))
+
+	//Route that hides an approved review (Admin only)
+	router.POST("/admin/reviews/:review_id/hide", middleware.AdminMiddleware(), controller.HideReview(client))
+
 	//Route that creates and insert one movie to movies collection in DB
 	router.POST("/addmovie", controller.AddMovie(client))
 
 	//Route that updates movie review
-	router.PATCH("/updatereview/:imdb_id", controller.AdminReviewUpdate(client))
+	router.PATCH("/updatereview/:imdb_id", controller.AdminReviewUpdate(client, classifier))
 
 	//Route that fecthes recommended movies for user
 	router.GET("/recommendedmovies", controller.GetRecommendedMovies(client))
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	controller "github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/controllers"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/middleware"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/storage"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Setup routes serving files through signed URLs (issued by GET /movie/:imdb_id/playback). Must be called before
+// SetupProtectedRoutes since they are not protected by the access token cookie
+func SetupSignedRoutes(router *gin.Engine, client *mongo.Client, store storage.BlobStore) {
+	signed := router.Group("/signed", middleware.SignedURLMiddleware())
+
+	//Route that streams the video of a movie with range request support
+	signed.GET("/stream/:imdb_id", controller.StreamMovie(client, store))
+
+	//Route that returns the HLS master playlist of a movie
+	signed.GET("/stream/:imdb_id/hls/master.m3u8", controller.GetHLSMasterPlaylist(client))
+
+	//Route that returns the playlist or a segment of an HLS rendition
+	signed.GET("/stream/:imdb_id/hls/:rendition/:file", controller.GetHLSFile(client, store))
+}
@@ -2,17 +2,22 @@ package routes
 
 import (
 	controller "github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/controllers"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/middleware"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/storage"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 // Setup protected routes
-func SetupUnProtectedRoutes(router *gin.Engine, client *mongo.Client) {
+func SetupUnProtectedRoutes(router *gin.Engine, client *mongo.Client, store storage.BlobStore) {
 	//NO MIDDLEWARE BECAUSE UNPROTECTED ROUTES
 	//UNPROTECTED ROUTES
 
-	//Route that returns all movies from DB
-	router.GET("/movies", controller.GetMovies(client))
+	//Route that returns all movies from DB. Signed in users also get their watchlist flags
+	router.GET("/movies", middleware.OptionalAuthMiddleware(), controller.GetMovies(client))
+
+	//Route that searches movies by title, review and genre ordered by relevance
+	router.GET("/movies/search", middleware.OptionalAuthMiddleware(), controller.SearchMovies(client))
 
 	//Route that creates and insert one user to users collection in DB
 	router.POST("/register", controller.RegisterUser(client))
@@ -23,6 +28,12 @@ func SetupUnProtectedRoutes(router *gin.Engine, client *mongo.Client) {
 	//Route that returns all genres form genres collection in mongodb
 	router.GET("/genres", controller.GetGenres(client))
 
+	//Route that returns all rankings sorted by value
+	router.GET("/rankings", controller.ListRankings(client))
+
+	//Route that serves uploaded posters, backdrops and their thumbnails
+	router.GET("/assets/*key", controller.ServeImageAsset(store))
+
 	//Route that logouts a user
 	router.POST("/logout", controller.LogoutHandler(client))
 
@@ -0,0 +1,172 @@
+// Package seed loads the JSON fixtures (genres, rankings, sample movies and an admin user) that turn an empty database
+// into a working MagicStream instance. Fixtures are embedded on the binary and grouped by version (fixtures/v1, ...)
+package seed
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// Fixtures version loaded when none is given
+const LatestVersion = "v1"
+
+// Options of a seed run
+type Options struct {
+	Version       string //Fixtures version, defaults to LatestVersion
+	AdminEmail    string //Overrides the email of the admin fixture
+	AdminPassword string //Password of the admin user, the admin is not created when empty
+}
+
+// Amount of documents inserted on every collection. Documents that already existed are left untouched
+type Report struct {
+	Version  string `json:"version"`
+	Genres   int    `json:"genres"`
+	Rankings int    `json:"rankings"`
+	Movies   int    `json:"movies"`
+	Users    int    `json:"users"`
+}
+
+var validate = validator.New()
+
+// Function that loads the fixtures of opts.Version. Every document is inserted only when its key (genre_id, ranking_value,
+// imdb_id, email) is not on the database yet, so running it several times is safe and never overwrites local changes
+func Run(ctx context.Context, client *mongo.Client, opts Options) (Report, error) {
+	if opts.Version == "" {
+		opts.Version = LatestVersion
+	}
+
+	report := Report{Version: opts.Version}
+	dir := path.Join("fixtures", opts.Version)
+
+	if _, err := fs.Stat(fixtures, dir); err != nil {
+		return report, fmt.Errorf("unknown fixtures version %q", opts.Version)
+	}
+
+	var genres []models.Genre
+	if err := readFixture(dir, "genres.json", &genres); err != nil {
+		return report, err
+	}
+
+	var rankings []models.Ranking
+	if err := readFixture(dir, "rankings.json", &rankings); err != nil {
+		return report, err
+	}
+
+	var movies []models.Movie
+	if err := readFixture(dir, "movies.json", &movies); err != nil {
+		return report, err
+	}
+
+	var err error
+
+	report.Genres, err = insertMissing(ctx, client, "genres", genres, func(genre models.Genre) bson.M {
+		return bson.M{"genre_id": genre.GenreID}
+	})
+	if err != nil {
+		return report, err
+	}
+
+	report.Rankings, err = insertMissing(ctx, client, "rankings", rankings, func(ranking models.Ranking) bson.M {
+		return bson.M{"ranking_value": ranking.RankingValue}
+	})
+	if err != nil {
+		return report, err
+	}
+
+	report.Movies, err = insertMissing(ctx, client, "movies", movies, func(movie models.Movie) bson.M {
+		return bson.M{"imdb_id": movie.ImdbID}
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if opts.AdminPassword == "" {
+		return report, nil
+	}
+
+	var admin models.User
+	if err := readFixture(dir, "admin.json", &admin); err != nil {
+		return report, err
+	}
+
+	if opts.AdminEmail != "" {
+		admin.Email = opts.AdminEmail
+	}
+	admin.Password = opts.AdminPassword
+
+	if err := validate.Struct(admin); err != nil {
+		return report, fmt.Errorf("invalid admin user: %w", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(admin.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return report, err
+	}
+
+	admin.UserID = bson.NewObjectID().Hex()
+	admin.Password = string(hashedPassword)
+	admin.CreatedAt = time.Now()
+	admin.UpdatedAt = time.Now()
+
+	report.Users, err = insertMissing(ctx, client, "users", []models.User{admin}, func(user models.User) bson.M {
+		return bson.M{"email": user.Email}
+	})
+
+	return report, err
+}
+
+// Function that decodes a fixture file of dir into target
+func readFixture(dir, name string, target any) error {
+	data, err := fixtures.ReadFile(path.Join(dir, name))
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("invalid fixture %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Function that inserts the documents whose key filter matches nothing on collection and returns how many were inserted
+func insertMissing[T any](ctx context.Context, client *mongo.Client, collection string, documents []T, key func(T) bson.M) (int, error) {
+	var coll *mongo.Collection = database.OpenCollection(collection, client)
+
+	inserted := 0
+	for _, document := range documents {
+		if err := validate.Struct(document); err != nil {
+			return inserted, fmt.Errorf("invalid %s fixture: %w", collection, err)
+		}
+
+		//$setOnInsert only writes when the upsert creates the document
+		result, err := coll.UpdateOne(ctx, key(document), bson.M{"$setOnInsert": document}, options.UpdateOne().SetUpsert(true))
+
+		if err != nil {
+			return inserted, err
+		}
+
+		if result.UpsertedCount > 0 {
+			inserted++
+		}
+	}
+
+	return inserted, nil
+}
@@ -0,0 +1,93 @@
+// Package sentiment classifies the text of reviews into one of the rankings stored on the rankings collection.
+// The classifier is an interface so the LLM backed implementation can be replaced by a local fake
+package sentiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/tmc/langchaingo/llms"
+)
+
+var ErrUnknownRanking = errors.New("classifier answered a ranking that does not exist")
+var ErrNoRankings = errors.New("there are no rankings the classifier can choose")
+
+// Ranking chosen for a review together with how sure the classifier is and why
+type Classification struct {
+	Ranking    models.Ranking
+	Confidence float64 //From 0 to 1
+	Rationale  string
+}
+
+// Classifier is implemented by every review classifier
+type Classifier interface {
+	//Classify returns the ranking matching the sentiment of review. Rankings excluded from AI are never returned
+	Classify(ctx context.Context, review string, rankings []models.Ranking) (Classification, error)
+}
+
+// Function that opens the classifier configured by REVIEW_CLASSIFIER environment variable: llm (default, openai is
+// kept as an alias from when OpenAI was the only model) asks model, fake never leaves the process.
+// REVIEW_RANKING_ATTEMPTS sets how many answers the model gets to give a valid ranking
+func NewFromEnv(model llms.Model) (Classifier, error) {
+	backend := os.Getenv("REVIEW_CLASSIFIER")
+
+	attempts := DefaultAttempts
+	if value := os.Getenv("REVIEW_RANKING_ATTEMPTS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return nil, fmt.Errorf("invalid REVIEW_RANKING_ATTEMPTS %q, expected a positive number", value)
+		}
+		attempts = parsed
+	}
+
+	switch backend {
+	case "", "llm", "openai":
+		return NewLLMClassifier(model, attempts), nil
+	case "fake":
+		return &Fake{}, nil
+	default:
+		return nil, fmt.Errorf("unknown REVIEW_CLASSIFIER %q", backend)
+	}
+}
+
+// Function that returns the rankings a classifier can choose, keeping their order
+func Selectable(rankings []models.Ranking) []models.Ranking {
+	selectable := []models.Ranking{}
+
+	for _, ranking := range rankings {
+		if !ranking.ExcludedFromAI {
+			selectable = append(selectable, ranking)
+		}
+	}
+
+	return selectable
+}
+
+// Function that returns the names of rankings
+func RankingNames(rankings []models.Ranking) []string {
+	names := make([]string, 0, len(rankings))
+
+	for _, ranking := range rankings {
+		names = append(names, ranking.RankingName)
+	}
+
+	return names
+}
+
+// Function that returns the selectable ranking named name. Case and surrounding spaces are ignored
+func MatchRanking(name string, rankings []models.Ranking) (models.Ranking, error) {
+	name = strings.TrimSpace(name)
+
+	for _, ranking := range Selectable(rankings) {
+		if strings.EqualFold(ranking.RankingName, name) {
+			return models.Ranking{RankingValue: ranking.RankingValue, RankingName: ranking.RankingName}, nil
+		}
+	}
+
+	return models.Ranking{}, fmt.Errorf("%w: %q", ErrUnknownRanking, name)
+}
@@ -0,0 +1,56 @@
+package sentiment
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+)
+
+// Classifier that never leaves the process, used for local development and tests. It answers RankingName, or the first
+// ranking the AI can choose when RankingName is empty. When Err is set every call fails with it
+type Fake struct {
+	RankingName string
+	Err         error
+
+	mu      sync.Mutex
+	reviews []string
+}
+
+func (f *Fake) Classify(ctx context.Context, review string, rankings []models.Ranking) (Classification, error) {
+	f.mu.Lock()
+	f.reviews = append(f.reviews, review)
+	f.mu.Unlock()
+
+	if f.Err != nil {
+		return Classification{}, f.Err
+	}
+
+	var ranking models.Ranking
+
+	if f.RankingName != "" {
+		matched, err := MatchRanking(f.RankingName, rankings)
+		if err != nil {
+			return Classification{}, err
+		}
+		ranking = matched
+	} else {
+		selectable := Selectable(rankings)
+
+		if len(selectable) == 0 {
+			return Classification{}, ErrNoRankings
+		}
+
+		ranking = models.Ranking{RankingValue: selectable[0].RankingValue, RankingName: selectable[0].RankingName}
+	}
+
+	return Classification{Ranking: ranking, Confidence: 1, Rationale: "ranked by the fake classifier"}, nil
+}
+
+// Function that returns the reviews classified so far in call order
+func (f *Fake) Reviews() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.reviews...)
+}
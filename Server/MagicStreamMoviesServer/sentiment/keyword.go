@@ -0,0 +1,120 @@
+package sentiment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Words counted by the keyword model
+var positiveKeywords = map[string]bool{
+	"amazing": true, "awesome": true, "beautiful": true, "best": true, "brilliant": true, "enjoyed": true,
+	"excellent": true, "fantastic": true, "good": true, "great": true, "incredible": true, "love": true,
+	"loved": true, "magnificent": true, "masterpiece": true, "perfect": true, "superb": true, "wonderful": true,
+}
+
+var negativeKeywords = map[string]bool{
+	"awful": true, "bad": true, "boring": true, "disappointing": true, "dull": true, "hate": true,
+	"hated": true, "horrible": true, "mess": true, "poor": true, "terrible": true, "waste": true,
+	"weak": true, "worst": true,
+}
+
+// Model that never leaves the process and always gives the same answer for the same review. It reads the allowed
+// rankings (best first) and the review from the messages built by RankingMessages, scores the review by counting
+// positive and negative keywords and answers the ranking at the matching position in the JSON format of RankingMessages
+type KeywordModel struct{}
+
+func NewKeywordModel() *KeywordModel {
+	return &KeywordModel{}
+}
+
+func (k *KeywordModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	names := allowedRankings(firstMessageText(messages, llms.ChatMessageTypeSystem))
+
+	if len(names) == 0 {
+		return nil, errors.New("keyword model needs the allowed rankings line built by RankingMessages")
+	}
+
+	//The review is the first user message, later ones are corrections
+	score, matched := keywordScore(firstMessageText(messages, llms.ChatMessageTypeHuman))
+
+	//Score goes from -1 (negative) to 1 (positive), rankings go from best to worst
+	index := int(math.Round((1 - score) / 2 * float64(len(names)-1)))
+
+	//More keywords found, more certain the answer
+	confidence := 0.3
+	if matched > 0 {
+		confidence = math.Min(0.5+0.1*float64(matched), 0.9)
+	}
+
+	answer, err := json.Marshal(rankingAnswer{
+		RankingName: names[index],
+		Confidence:  &confidence,
+		Rationale:   fmt.Sprintf("Keyword score %.2f from %d sentiment keywords", score, matched),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: string(answer)}}}, nil
+}
+
+func (k *KeywordModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, k, prompt, options...)
+}
+
+// Function that returns the rankings listed on the allowed rankings line of the instructions
+func allowedRankings(instructions string) []string {
+	var names []string
+
+	for _, line := range strings.Split(instructions, "\n") {
+		if list, ok := strings.CutPrefix(strings.TrimSpace(line), allowedRankingsPrefix); ok {
+			names = splitNames(list)
+		}
+	}
+
+	return names
+}
+
+func splitNames(list string) []string {
+	var names []string
+
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Function that returns (positive - negative) / (positive + negative) keywords of text, 0 when it has none, and the
+// amount of keywords found
+func keywordScore(text string) (float64, int) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	positive, negative := 0, 0
+	for _, word := range words {
+		if positiveKeywords[word] {
+			positive++
+		}
+		if negativeKeywords[word] {
+			negative++
+		}
+	}
+
+	if positive+negative == 0 {
+		return 0, 0
+	}
+
+	return float64(positive-negative) / float64(positive+negative), positive + negative
+}
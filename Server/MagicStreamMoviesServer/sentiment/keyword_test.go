@@ -0,0 +1,81 @@
+package sentiment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Rankings from best to worst as stored on the rankings collection
+var testRankings = []models.Ranking{
+	{RankingValue: 1, RankingName: "Excellent"},
+	{RankingValue: 2, RankingName: "Good"},
+	{RankingValue: 3, RankingName: "Okay"},
+	{RankingValue: 4, RankingName: "Bad"},
+	{RankingValue: 5, RankingName: "Terrible"},
+	{RankingValue: 999, RankingName: "Not_Ranked", ExcludedFromAI: true},
+}
+
+func TestKeywordModel(t *testing.T) {
+	tests := []struct {
+		review     string
+		want       string
+		confidence float64
+	}{
+		{"A masterpiece, I loved every minute", "Excellent", 0.7},
+		{"Great cast and a wonderful score, only the ending was weak", "Good", 0.8},
+		{"It is a movie about a boat", "Okay", 0.3},
+		{"Good actors lost in a boring plot", "Okay", 0.7},
+		{"Boring, dull and a waste of time, the worst of the year", "Terrible", 0.9},
+	}
+
+	classifier := NewLLMClassifier(NewKeywordModel(), 1)
+
+	for _, test := range tests {
+		t.Run(test.want+"/"+test.review, func(t *testing.T) {
+			classification, err := classifier.Classify(context.Background(), test.review, testRankings)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if classification.Ranking.RankingName != test.want {
+				t.Errorf("ranking = %s, want %s", classification.Ranking.RankingName, test.want)
+			}
+			if classification.Confidence < test.confidence-1e-9 || classification.Confidence > test.confidence+1e-9 {
+				t.Errorf("confidence = %v, want %v", classification.Confidence, test.confidence)
+			}
+			if classification.Rationale == "" {
+				t.Errorf("rationale is empty")
+			}
+		})
+	}
+}
+
+func TestKeywordModelIsDeterministic(t *testing.T) {
+	model := NewKeywordModel()
+	messages := RankingMessages(RankingNames(Selectable(testRankings)), "Brilliant but boring")
+
+	first, err := model.GenerateContent(context.Background(), messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := model.GenerateContent(context.Background(), messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Choices[0].Content != second.Choices[0].Content {
+		t.Errorf("answers differ: %q and %q", first.Choices[0].Content, second.Choices[0].Content)
+	}
+}
+
+func TestKeywordModelNeedsRankings(t *testing.T) {
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Loved it")}
+
+	if _, err := NewKeywordModel().GenerateContent(context.Background(), messages); err == nil {
+		t.Errorf("expected an error without the allowed rankings line")
+	}
+}
@@ -0,0 +1,26 @@
+package sentiment
+
+import (
+	"context"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Classifier that asks a language model for the ranking as JSON, giving it up to attempts tries to answer a valid one
+type LLMClassifier struct {
+	model    llms.Model
+	attempts int
+}
+
+func NewLLMClassifier(model llms.Model, attempts int) *LLMClassifier {
+	if attempts < 1 {
+		attempts = DefaultAttempts
+	}
+
+	return &LLMClassifier{model: model, attempts: attempts}
+}
+
+func (l *LLMClassifier) Classify(ctx context.Context, review string, rankings []models.Ranking) (Classification, error) {
+	return classifyStructured(ctx, l.model, review, rankings, l.attempts)
+}
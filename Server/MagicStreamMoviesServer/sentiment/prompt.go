@@ -0,0 +1,53 @@
+package sentiment
+
+import (
+	"os"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Prefix of the instruction line listing the rankings the model can answer. The keyword model reads it back
+const allowedRankingsPrefix = "Allowed rankings: "
+
+// Function that builds the messages asking a model to rank review: BASE_PROMPT_TEMPLATE with {rankings} replaced,
+// the allowed rankings line and the JSON answer format as system message, and the review as user message
+func RankingMessages(names []string, review string) []llms.MessageContent {
+	rankings := strings.Join(names, ",")
+
+	instructions := strings.Replace(os.Getenv("BASE_PROMPT_TEMPLATE"), "{rankings}", rankings, 1)
+	instructions = strings.TrimSpace(instructions + "\n" + allowedRankingsPrefix + rankings + "\n" + answerFormat(names))
+
+	return []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, instructions),
+		llms.TextParts(llms.ChatMessageTypeHuman, review),
+	}
+}
+
+// Function that returns the text of the first choice of a model response
+func responseText(response *llms.ContentResponse) (string, error) {
+	if response == nil || len(response.Choices) == 0 {
+		return "", ErrEmptyResponse
+	}
+
+	return response.Choices[0].Content, nil
+}
+
+// Function that returns the concatenated text parts of the first message with role
+func firstMessageText(messages []llms.MessageContent, role llms.ChatMessageType) string {
+	for _, message := range messages {
+		if message.Role != role {
+			continue
+		}
+
+		var text strings.Builder
+		for _, part := range message.Parts {
+			if textPart, ok := part.(llms.TextContent); ok {
+				text.WriteString(textPart.Text)
+			}
+		}
+		return text.String()
+	}
+
+	return ""
+}
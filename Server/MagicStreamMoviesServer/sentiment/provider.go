@@ -0,0 +1,74 @@
+package sentiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+var ErrEmptyResponse = errors.New("model returned an empty response")
+
+// Function that opens the language model configured by environment variables:
+//
+//	LLM_PROVIDER  openai (default), openai_compatible for local servers such as Ollama or llama.cpp, or keyword
+//	LLM_MODEL     model name, the provider default when empty
+//	LLM_BASE_URL  address of the openai_compatible server, for example http://localhost:11434/v1
+//	LLM_API_KEY   key of the openai_compatible server, most local servers do not need one
+func NewModelFromEnv() (llms.Model, error) {
+	provider := os.Getenv("LLM_PROVIDER")
+	modelName := os.Getenv("LLM_MODEL")
+
+	switch provider {
+	case "", "openai":
+		apiKey := os.Getenv("OPENAI_API_KEY")
+
+		//The server runs without AI features until the key is configured
+		if apiKey == "" {
+			return unavailableModel{errors.New("could not read open ai api key")}, nil
+		}
+
+		opts := []openai.Option{openai.WithToken(apiKey)}
+		if modelName != "" {
+			opts = append(opts, openai.WithModel(modelName))
+		}
+
+		return openai.New(opts...)
+
+	case "openai_compatible":
+		baseURL := os.Getenv("LLM_BASE_URL")
+
+		if baseURL == "" || modelName == "" {
+			return nil, errors.New("LLM_BASE_URL and LLM_MODEL are required by the openai_compatible provider")
+		}
+
+		apiKey := os.Getenv("LLM_API_KEY")
+		if apiKey == "" {
+			apiKey = "local"
+		}
+
+		return openai.New(openai.WithBaseURL(baseURL), openai.WithModel(modelName), openai.WithToken(apiKey))
+
+	case "keyword":
+		return NewKeywordModel(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q", provider)
+	}
+}
+
+// Model that fails every call with err
+type unavailableModel struct {
+	err error
+}
+
+func (u unavailableModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	return nil, u.err
+}
+
+func (u unavailableModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return "", u.err
+}
@@ -0,0 +1,115 @@
+package sentiment
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNewModelFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"openai", map[string]string{"LLM_PROVIDER": "openai", "OPENAI_API_KEY": "sk-test"}, ""},
+		{"keyword", map[string]string{"LLM_PROVIDER": "keyword"}, ""},
+		{"openai compatible", map[string]string{"LLM_PROVIDER": "openai_compatible", "LLM_BASE_URL": "http://localhost:11434/v1", "LLM_MODEL": "llama3"}, ""},
+		{"openai compatible without base url", map[string]string{"LLM_PROVIDER": "openai_compatible", "LLM_MODEL": "llama3"}, "LLM_BASE_URL and LLM_MODEL are required"},
+		{"openai compatible without model", map[string]string{"LLM_PROVIDER": "openai_compatible", "LLM_BASE_URL": "http://localhost:11434/v1"}, "LLM_BASE_URL and LLM_MODEL are required"},
+		{"unknown provider", map[string]string{"LLM_PROVIDER": "anthropic"}, `unknown LLM_PROVIDER "anthropic"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{"LLM_PROVIDER", "LLM_MODEL", "LLM_BASE_URL", "LLM_API_KEY", "OPENAI_API_KEY"} {
+				t.Setenv(name, test.env[name])
+			}
+
+			model, err := NewModelFromEnv()
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if model == nil {
+				t.Fatal("model is nil")
+			}
+		})
+	}
+}
+
+func TestNewModelFromEnvWithoutOpenAIKey(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "")
+	t.Setenv("OPENAI_API_KEY", "")
+
+	model, err := NewModelFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//The server starts, only the calls fail
+	if _, err := model.Call(context.Background(), "Loved it"); err == nil {
+		t.Errorf("expected calls to fail without OPENAI_API_KEY")
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	tests := []struct {
+		classifier   string
+		attempts     string
+		want         string
+		wantAttempts int
+		wantErr      string
+	}{
+		{"", "", "llm", DefaultAttempts, ""},
+		{"llm", "5", "llm", 5, ""},
+		{"openai", "", "llm", DefaultAttempts, ""},
+		{"fake", "", "fake", 0, ""},
+		{"bayes", "", "", 0, `unknown REVIEW_CLASSIFIER "bayes"`},
+		{"llm", "0", "", 0, "invalid REVIEW_RANKING_ATTEMPTS"},
+		{"llm", "many", "", 0, "invalid REVIEW_RANKING_ATTEMPTS"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.classifier+"/"+test.attempts, func(t *testing.T) {
+			t.Setenv("REVIEW_CLASSIFIER", test.classifier)
+			t.Setenv("REVIEW_RANKING_ATTEMPTS", test.attempts)
+
+			classifier, err := NewFromEnv(NewKeywordModel())
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			switch classifier := classifier.(type) {
+			case *LLMClassifier:
+				if test.want != "llm" {
+					t.Errorf("got the llm classifier, want %s", test.want)
+				}
+				if classifier.attempts != test.wantAttempts {
+					t.Errorf("attempts = %d, want %d", classifier.attempts, test.wantAttempts)
+				}
+			case *Fake:
+				if test.want != "fake" {
+					t.Errorf("got the fake classifier, want %s", test.want)
+				}
+			default:
+				t.Errorf("unexpected classifier %T", classifier)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package sentiment
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+var ErrScriptExhausted = errors.New("scripted model has no responses left")
+
+// Model for tests that answers the scripted responses in order and records the messages it received.
+// When Err is set every call fails with it
+type ScriptedModel struct {
+	Responses []string
+	Err       error
+
+	mu       sync.Mutex
+	next     int
+	requests [][]llms.MessageContent
+}
+
+func NewScriptedModel(responses ...string) *ScriptedModel {
+	return &ScriptedModel{Responses: responses}
+}
+
+func (s *ScriptedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, messages)
+
+	if s.Err != nil {
+		return nil, s.Err
+	}
+
+	if s.next >= len(s.Responses) {
+		return nil, ErrScriptExhausted
+	}
+
+	response := s.Responses[s.next]
+	s.next++
+
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: response}}}, nil
+}
+
+func (s *ScriptedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, s, prompt, options...)
+}
+
+// Function that returns the messages of every call received so far in call order
+func (s *ScriptedModel) Requests() [][]llms.MessageContent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([][]llms.MessageContent(nil), s.requests...)
+}
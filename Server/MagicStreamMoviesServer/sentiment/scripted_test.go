@@ -0,0 +1,76 @@
+package sentiment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestScriptedModel(t *testing.T) {
+	rateLimited := errors.New("rate limited")
+
+	tests := []struct {
+		name    string
+		model   *ScriptedModel
+		calls   int
+		want    []string
+		wantErr error
+	}{
+		{"answers in order", NewScriptedModel("first", "second"), 2, []string{"first", "second"}, nil},
+		{"exhausted", NewScriptedModel("only"), 2, []string{"only"}, ErrScriptExhausted},
+		{"no responses", NewScriptedModel(), 1, nil, ErrScriptExhausted},
+		{"error", &ScriptedModel{Responses: []string{"unused"}, Err: rateLimited}, 1, nil, rateLimited},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var answers []string
+			var err error
+
+			for i := 0; i < test.calls && err == nil; i++ {
+				var answer string
+				answer, err = test.model.Call(context.Background(), "prompt")
+				if err == nil {
+					answers = append(answers, answer)
+				}
+			}
+
+			if len(answers) != len(test.want) {
+				t.Fatalf("answers = %q, want %q", answers, test.want)
+			}
+			for i := range answers {
+				if answers[i] != test.want[i] {
+					t.Errorf("answer %d = %q, want %q", i, answers[i], test.want[i])
+				}
+			}
+
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("error = %v, want %v", err, test.wantErr)
+			}
+
+			if got := len(test.model.Requests()); got != test.calls {
+				t.Errorf("recorded %d requests, want %d", got, test.calls)
+			}
+		})
+	}
+}
+
+func TestScriptedModelRecordsMessages(t *testing.T) {
+	model := NewScriptedModel("answer")
+	messages := RankingMessages([]string{"Good", "Bad"}, "Loved it")
+
+	if _, err := model.GenerateContent(context.Background(), messages); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := model.Requests()
+	if len(requests) != 1 || len(requests[0]) != 2 {
+		t.Fatalf("requests = %v, want one request with two messages", requests)
+	}
+
+	if review := firstMessageText(requests[0], llms.ChatMessageTypeHuman); review != "Loved it" {
+		t.Errorf("recorded review = %q, want Loved it", review)
+	}
+}
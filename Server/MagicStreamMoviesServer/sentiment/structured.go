@@ -0,0 +1,140 @@
+package sentiment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Attempts a model gets to answer a valid ranking when REVIEW_RANKING_ATTEMPTS is not set
+const DefaultAttempts = 3
+
+// Longest rationale accepted from a model
+const maxRationaleLength = 500
+
+var ErrInvalidOutput = errors.New("invalid model output")
+
+// JSON object every model must answer
+type rankingAnswer struct {
+	RankingName string   `json:"ranking_name"`
+	Confidence  *float64 `json:"confidence"`
+	Rationale   string   `json:"rationale"`
+}
+
+// Error returned when a model did not answer a valid ranking on any attempt
+type InvalidOutputError struct {
+	Attempts int
+	Output   string //Last answer of the model
+	Err      error  //Why the last answer was rejected
+}
+
+func (e *InvalidOutputError) Error() string {
+	return fmt.Sprintf("model gave no valid ranking after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *InvalidOutputError) Unwrap() error {
+	return e.Err
+}
+
+// Function that asks model to rank review answering JSON. Invalid answers are sent back with a corrective message
+// until attempts run out, then an *InvalidOutputError is returned. Errors calling the model are returned right away
+func classifyStructured(ctx context.Context, model llms.Model, review string, rankings []models.Ranking, attempts int) (Classification, error) {
+	selectable := Selectable(rankings)
+
+	if len(selectable) == 0 {
+		return Classification{}, ErrNoRankings
+	}
+
+	names := RankingNames(selectable)
+	messages := RankingMessages(names, review)
+
+	var output string
+	var invalid error
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		response, err := model.GenerateContent(ctx, messages, llms.WithJSONMode())
+
+		if err != nil {
+			return Classification{}, err
+		}
+
+		output, err = responseText(response)
+
+		if err != nil {
+			return Classification{}, err
+		}
+
+		classification, err := parseAnswer(output, rankings)
+
+		if err == nil {
+			return classification, nil
+		}
+
+		invalid = err
+
+		//Show the model its own answer and what was wrong with it
+		messages = append(messages,
+			llms.TextParts(llms.ChatMessageTypeAI, output),
+			llms.TextParts(llms.ChatMessageTypeHuman, correctivePrompt(err, names)),
+		)
+	}
+
+	return Classification{}, &InvalidOutputError{Attempts: attempts, Output: output, Err: invalid}
+}
+
+// Function that parses and validates the JSON answer of a model
+func parseAnswer(output string, rankings []models.Ranking) (Classification, error) {
+	var answer rankingAnswer
+
+	if err := json.Unmarshal([]byte(extractJSON(output)), &answer); err != nil {
+		return Classification{}, fmt.Errorf("%w: answer is not a JSON object: %v", ErrInvalidOutput, err)
+	}
+
+	ranking, err := MatchRanking(answer.RankingName, rankings)
+
+	if err != nil {
+		return Classification{}, fmt.Errorf("%w: %w", ErrInvalidOutput, err)
+	}
+
+	if answer.Confidence == nil || *answer.Confidence < 0 || *answer.Confidence > 1 {
+		return Classification{}, fmt.Errorf("%w: confidence must be a number from 0 to 1", ErrInvalidOutput)
+	}
+
+	rationale := strings.TrimSpace(answer.Rationale)
+
+	if rationale == "" || utf8.RuneCountInString(rationale) > maxRationaleLength {
+		return Classification{}, fmt.Errorf("%w: rationale must have from 1 to %d characters", ErrInvalidOutput, maxRationaleLength)
+	}
+
+	return Classification{Ranking: ranking, Confidence: *answer.Confidence, Rationale: rationale}, nil
+}
+
+// Function that returns the JSON object inside output, dropping markdown fences or text models add around it
+func extractJSON(output string) string {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+
+	if start == -1 || end < start {
+		return output
+	}
+
+	return output[start : end+1]
+}
+
+// Function that builds the message asking the model to fix an invalid answer
+func correctivePrompt(err error, names []string) string {
+	return "Your previous answer was invalid (" + err.Error() + "). " + answerFormat(names)
+}
+
+// Function that describes the JSON answer expected from the model
+func answerFormat(names []string) string {
+	return `Answer only with a JSON object like {"ranking_name": "` + names[0] + `", "confidence": 0.8, "rationale": "short reason"} ` +
+		"where ranking_name is exactly one of: " + strings.Join(names, ",") +
+		", confidence is a number from 0 to 1 and rationale is one sentence explaining the choice."
+}
@@ -0,0 +1,171 @@
+package sentiment
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestParseAnswer(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    string
+		wantErr error
+	}{
+		{"valid", `{"ranking_name": "Good", "confidence": 0.8, "rationale": "Praises the cast"}`, "Good", nil},
+		{"case and spaces", `{"ranking_name": " terrible ", "confidence": 0, "rationale": "Hated it"}`, "Terrible", nil},
+		{"fenced", "```json\n{\"ranking_name\": \"Bad\", \"confidence\": 1, \"rationale\": \"Too long\"}\n```", "Bad", nil},
+		{"not json", "The review is Good", "", ErrInvalidOutput},
+		{"broken json", `{"ranking_name": "Good", "confidence": }`, "", ErrInvalidOutput},
+		{"unknown ranking", `{"ranking_name": "Masterpiece", "confidence": 0.9, "rationale": "Loved it"}`, "", ErrUnknownRanking},
+		{"excluded ranking", `{"ranking_name": "Not_Ranked", "confidence": 0.9, "rationale": "Unsure"}`, "", ErrUnknownRanking},
+		{"missing ranking", `{"confidence": 0.9, "rationale": "Loved it"}`, "", ErrUnknownRanking},
+		{"missing confidence", `{"ranking_name": "Good", "rationale": "Praises the cast"}`, "", ErrInvalidOutput},
+		{"confidence above 1", `{"ranking_name": "Good", "confidence": 80, "rationale": "Praises the cast"}`, "", ErrInvalidOutput},
+		{"negative confidence", `{"ranking_name": "Good", "confidence": -0.1, "rationale": "Praises the cast"}`, "", ErrInvalidOutput},
+		{"confidence as text", `{"ranking_name": "Good", "confidence": "high", "rationale": "Praises the cast"}`, "", ErrInvalidOutput},
+		{"empty rationale", `{"ranking_name": "Good", "confidence": 0.8, "rationale": "  "}`, "", ErrInvalidOutput},
+		{"rationale too long", `{"ranking_name": "Good", "confidence": 0.8, "rationale": "` + strings.Repeat("é", maxRationaleLength+1) + `"}`, "", ErrInvalidOutput},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			classification, err := parseAnswer(test.output, testRankings)
+
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("error = %v, want %v", err, test.wantErr)
+				}
+				//Every rejected answer is invalid output, whatever the reason
+				if !errors.Is(err, ErrInvalidOutput) {
+					t.Errorf("error = %v, want it to wrap %v", err, ErrInvalidOutput)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if classification.Ranking.RankingName != test.want {
+				t.Errorf("ranking = %s, want %s", classification.Ranking.RankingName, test.want)
+			}
+		})
+	}
+}
+
+func TestParseAnswerAcceptsLongestRationale(t *testing.T) {
+	rationale := strings.Repeat("é", maxRationaleLength)
+
+	classification, err := parseAnswer(`{"ranking_name": "Okay", "confidence": 0.5, "rationale": "`+rationale+`"}`, testRankings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if classification.Rationale != rationale || classification.Confidence != 0.5 || classification.Ranking.RankingValue != 3 {
+		t.Errorf("classification = %+v", classification)
+	}
+}
+
+func TestClassifyStructuredRetries(t *testing.T) {
+	model := NewScriptedModel(
+		"I think this review is Good",
+		"```json\n{\"ranking_name\": \"good\", \"confidence\": 0.75, \"rationale\": \"Praises the cast\"}\n```",
+	)
+
+	classification, err := NewLLMClassifier(model, 3).Classify(context.Background(), "Great cast", testRankings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if classification.Ranking.RankingName != "Good" || classification.Ranking.RankingValue != 2 {
+		t.Errorf("ranking = %+v, want Good (2)", classification.Ranking)
+	}
+	if classification.Confidence != 0.75 || classification.Rationale != "Praises the cast" {
+		t.Errorf("classification = %+v", classification)
+	}
+
+	requests := model.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("model was called %d times, want 2", len(requests))
+	}
+
+	//The second call carries the invalid answer and a correction on top of the first messages
+	retry := requests[1]
+	if len(retry) != 4 {
+		t.Fatalf("retry has %d messages, want 4", len(retry))
+	}
+	if retry[2].Role != llms.ChatMessageTypeAI || firstMessageText(retry[2:3], llms.ChatMessageTypeAI) != "I think this review is Good" {
+		t.Errorf("retry does not show the model its previous answer: %+v", retry[2])
+	}
+	if correction := firstMessageText(retry[3:], llms.ChatMessageTypeHuman); !strings.Contains(correction, "previous answer was invalid") {
+		t.Errorf("correction = %q", correction)
+	}
+}
+
+func TestClassifyStructuredExhaustsAttempts(t *testing.T) {
+	last := `{"ranking_name": "Not_Ranked", "confidence": 0.5, "rationale": "Unsure"}`
+	model := NewScriptedModel("not json", `{"ranking_name": "Good"}`, last, "never asked")
+
+	_, err := NewLLMClassifier(model, 3).Classify(context.Background(), "Fine I guess", testRankings)
+
+	var invalid *InvalidOutputError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("error = %v, want an *InvalidOutputError", err)
+	}
+
+	if invalid.Attempts != 3 || invalid.Output != last {
+		t.Errorf("attempts = %d, output = %q, want 3 attempts and the last answer", invalid.Attempts, invalid.Output)
+	}
+	if !errors.Is(err, ErrInvalidOutput) || !errors.Is(err, ErrUnknownRanking) {
+		t.Errorf("error = %v, want it to wrap why the last answer was rejected", err)
+	}
+
+	if calls := len(model.Requests()); calls != 3 {
+		t.Errorf("model was called %d times, want 3", calls)
+	}
+}
+
+func TestClassifyStructuredModelErrors(t *testing.T) {
+	unavailable := errors.New("connection refused")
+
+	tests := []struct {
+		name    string
+		model   *ScriptedModel
+		wantErr error
+	}{
+		{"call error", &ScriptedModel{Err: unavailable}, unavailable},
+		{"script exhausted", NewScriptedModel(), ErrScriptExhausted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewLLMClassifier(test.model, 3).Classify(context.Background(), "Great cast", testRankings)
+
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("error = %v, want %v", err, test.wantErr)
+			}
+
+			//Errors calling the model are not retried
+			if calls := len(test.model.Requests()); calls != 1 {
+				t.Errorf("model was called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestClassifyStructuredWithoutRankings(t *testing.T) {
+	model := NewScriptedModel(`{"ranking_name": "Not_Ranked", "confidence": 1, "rationale": "Only choice"}`)
+
+	_, err := NewLLMClassifier(model, 3).Classify(context.Background(), "Great cast", testRankings[5:])
+
+	if !errors.Is(err, ErrNoRankings) {
+		t.Errorf("error = %v, want %v", err, ErrNoRankings)
+	}
+	if calls := len(model.Requests()); calls != 0 {
+		t.Errorf("model was called %d times, want none", calls)
+	}
+}
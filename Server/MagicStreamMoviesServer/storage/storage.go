@@ -0,0 +1,58 @@
+// Package storage holds the blob stores where uploaded media (posters, backdrops, videos) is kept. Files are addressed by
+// slash separated keys such as images/3f2a.../w342.jpg so the backend can be swapped without touching the callers
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+var ErrNotFound = errors.New("blob not found")
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Information about a stored blob
+type BlobInfo struct {
+	Size        int64
+	ModTime     time.Time
+	ContentType string
+}
+
+// Blob opened for reading. It can seek so it can be served with http.ServeContent (range requests)
+type Blob interface {
+	io.ReadSeekCloser
+	Info() BlobInfo
+}
+
+// BlobStore is implemented by every storage backend
+type BlobStore interface {
+	//Put writes the content of r under key, replacing any blob with the same key
+	Put(ctx context.Context, key string, r io.Reader) error
+	//Open returns the blob stored under key or ErrNotFound
+	Open(ctx context.Context, key string) (Blob, error)
+	//Exists reports whether a blob is stored under key
+	Exists(ctx context.Context, key string) (bool, error)
+	//Delete removes the blob stored under key, deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+	//List returns the keys stored below the prefix directory sorted by name, an empty list when there is none
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Function that opens the blob store configured by MEDIA_STORAGE (only "fs" for now) and MEDIA_DIR environment variables
+func NewFromEnv() (BlobStore, error) {
+	backend := os.Getenv("MEDIA_STORAGE")
+
+	switch backend {
+	case "", "fs":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		return NewFileSystemStore(dir)
+	default:
+		return nil, fmt.Errorf("unknown MEDIA_STORAGE %q", backend)
+	}
+}
@@ -0,0 +1,74 @@
+//File containing code to resolve the people credited on a movie
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Error returned when a movie credits a person that is not on people collection
+type UnknownPersonError struct {
+	PersonID string
+}
+
+func (e UnknownPersonError) Error() string {
+	return fmt.Sprintf("person %s does not exist", e.PersonID)
+}
+
+// Function that checks every credited person exists and fills movie Directors and Cast with their names in billing order
+func ApplyCredits(ctx context.Context, client *mongo.Client, movie *models.Movie) error {
+	if len(movie.Credits) == 0 {
+		return nil
+	}
+
+	var ids bson.A
+	for _, credit := range movie.Credits {
+		ids = append(ids, credit.PersonID)
+	}
+
+	var peopleCollection *mongo.Collection = database.OpenCollection("people", client)
+
+	cursor, err := peopleCollection.Find(ctx, bson.M{"person_id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+
+	var people []models.Person
+	if err := cursor.All(ctx, &people); err != nil {
+		return err
+	}
+
+	names := map[string]string{}
+	for _, person := range people {
+		names[person.PersonID] = person.Name
+	}
+
+	credits := append([]models.Credit(nil), movie.Credits...)
+	sort.SliceStable(credits, func(i, j int) bool { return credits[i].Order < credits[j].Order })
+
+	movie.Directors = []string{}
+	movie.Cast = []string{}
+
+	for _, credit := range credits {
+		name, ok := names[credit.PersonID]
+		if !ok {
+			return UnknownPersonError{credit.PersonID}
+		}
+
+		switch credit.Role {
+		case "director":
+			movie.Directors = append(movie.Directors, name)
+		case "actor":
+			movie.Cast = append(movie.Cast, name)
+		}
+	}
+
+	return nil
+}
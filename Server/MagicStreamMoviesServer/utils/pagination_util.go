@@ -0,0 +1,311 @@
+//File containing code to paginate collections using opaque keyset cursors
+
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/Fernando0743/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Default and maximum amount of documents returned on a single page
+const DefaultPageLimit int64 = 20
+const MaxPageLimit int64 = 100
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageCursor is the position of a document inside a sorted listing. It is sent to the client as an opaque base64 string
+type PageCursor struct {
+	Field     string        `bson:"f"`           //Field the listing was sorted by when cursor was created
+	Ascending bool          `bson:"a"`           //Sort direction of the listing when cursor was created
+	Value     bson.RawValue `bson:"v,omitempty"` //Value of the sort field on the document (empty when sorting by _id)
+	ID        bson.ObjectID `bson:"id"`          //_id of the document, used to break ties between equal sort values
+	Backward  bool          `bson:"b"`           //True for prev cursors, the page is read in reverse order
+}
+
+// KeysetQuery describes how a listing is sorted and where the requested page starts
+type KeysetQuery struct {
+	Field     string //Sort field, use "_id" to sort by insertion date
+	Ascending bool
+	Limit     int64
+	Cursor    *PageCursor
+}
+
+// Function that converts a cursor into the opaque string returned to the client
+func EncodeCursor(cursor PageCursor) (string, error) {
+	data, err := bson.Marshal(cursor)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Function that decodes the opaque cursor string received from the client
+func DecodeCursor(cursorString string) (*PageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursorString)
+
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor PageCursor
+	if err := bson.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// Function that returns the filter selecting documents placed after the cursor given the sort direction
+func keysetFilter(field string, ascending bool, cursor *PageCursor) bson.M {
+	operator := "$gt"
+	if !ascending {
+		operator = "$lt"
+	}
+
+	if field == "_id" {
+		return bson.M{"_id": bson.M{operator: cursor.ID}}
+	}
+
+	//Missing values sort as null, before every other value. Comparison operators never match null so they need their own conditions
+	if cursor.Value.Type == bson.TypeNull {
+		sameValue := bson.M{field: nil, "_id": bson.M{operator: cursor.ID}}
+		if ascending {
+			return bson.M{"$or": bson.A{sameValue, bson.M{field: bson.M{"$ne": nil}}}}
+		}
+		return sameValue
+	}
+
+	//Documents with a greater sort value, or the same sort value and a greater _id
+	after := bson.A{
+		bson.M{field: bson.M{operator: cursor.Value}},
+		bson.M{field: cursor.Value, "_id": bson.M{operator: cursor.ID}},
+	}
+
+	if !ascending {
+		after = append(after, bson.M{field: nil})
+	}
+
+	return bson.M{"$or": after}
+}
+
+// Function that builds a cursor pointing at a raw document of the listing
+func cursorFromDocument(query KeysetQuery, doc bson.Raw, backward bool) (string, error) {
+	field := query.Field
+	cursor := PageCursor{Field: field, Ascending: query.Ascending, Backward: backward}
+
+	id, ok := doc.Lookup("_id").ObjectIDOK()
+	if !ok {
+		return "", errors.New("document has no object id")
+	}
+	cursor.ID = id
+
+	if field != "_id" {
+		value, err := doc.LookupErr(strings.Split(field, ".")...)
+		if err != nil {
+			value = bson.RawValue{Type: bson.TypeNull}
+		}
+		cursor.Value = value
+	}
+
+	return EncodeCursor(cursor)
+}
+
+// Function that reports whether the cursor of query was created by a listing with the same sort field and direction.
+// A cursor from another sort option would silently return the wrong page
+func cursorMatches(query KeysetQuery) bool {
+	return query.Cursor == nil || (query.Cursor.Field == query.Field && query.Cursor.Ascending == query.Ascending)
+}
+
+// Function that queries one page of documents matching filter and returns them with the total count and next/prev cursors
+func FindPage[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, query KeysetQuery) (models.Page[T], error) {
+	var page models.Page[T]
+
+	if !cursorMatches(query) {
+		return page, ErrInvalidCursor
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+
+	if err != nil {
+		return page, err
+	}
+	page.Total = total
+
+	//prev cursors read the listing in the opposite direction starting from the first document of the current page
+	backward := query.Cursor != nil && query.Cursor.Backward
+	ascending := query.Ascending != backward
+
+	pageFilter := filter
+	if query.Cursor != nil {
+		pageFilter = bson.M{"$and": bson.A{filter, keysetFilter(query.Field, ascending, query.Cursor)}}
+	}
+
+	//Fetch one extra document to know if there are more pages
+	findOptions := options.Find().SetSort(keysetSort(query.Field, ascending)).SetLimit(query.Limit + 1)
+
+	cursor, err := collection.Find(ctx, pageFilter, findOptions)
+
+	if err != nil {
+		return page, err
+	}
+	defer cursor.Close(ctx)
+
+	return readPage(ctx, cursor, page, query)
+}
+
+// Function that runs an aggregation pipeline and returns one page of its output with the total count and next/prev cursors.
+// The sort field can be computed by the pipeline stages (for example a relevance score)
+func AggregatePage[T any](ctx context.Context, collection *mongo.Collection, stages mongo.Pipeline, query KeysetQuery) (models.Page[T], error) {
+	var page models.Page[T]
+
+	if !cursorMatches(query) {
+		return page, ErrInvalidCursor
+	}
+
+	//Count every document produced by the stages
+	countPipeline := append(append(mongo.Pipeline{}, stages...), bson.D{{Key: "$count", Value: "total"}})
+
+	countCursor, err := collection.Aggregate(ctx, countPipeline)
+
+	if err != nil {
+		return page, err
+	}
+
+	var counts []struct {
+		Total int64 `bson:"total"`
+	}
+	if err := countCursor.All(ctx, &counts); err != nil {
+		return page, err
+	}
+
+	if len(counts) > 0 {
+		page.Total = counts[0].Total
+	}
+
+	backward := query.Cursor != nil && query.Cursor.Backward
+	ascending := query.Ascending != backward
+
+	pipeline := append(mongo.Pipeline{}, stages...)
+	if query.Cursor != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: keysetFilter(query.Field, ascending, query.Cursor)}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: keysetSort(query.Field, ascending)}},
+		bson.D{{Key: "$limit", Value: query.Limit + 1}},
+	)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+
+	if err != nil {
+		return page, err
+	}
+	defer cursor.Close(ctx)
+
+	return readPage(ctx, cursor, page, query)
+}
+
+// Function that returns the sort document of a keyset listing, _id breaks ties between equal sort values
+func keysetSort(field string, ascending bool) bson.D {
+	direction := 1
+	if !ascending {
+		direction = -1
+	}
+
+	sort := bson.D{{Key: field, Value: direction}}
+	if field != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: direction})
+	}
+
+	return sort
+}
+
+// Function that reads the documents of a page query (limit + 1 documents) and fills the page items and cursors
+func readPage[T any](ctx context.Context, cursor *mongo.Cursor, page models.Page[T], query KeysetQuery) (models.Page[T], error) {
+	backward := query.Cursor != nil && query.Cursor.Backward
+
+	var docs []bson.Raw
+	for cursor.Next(ctx) {
+		docs = append(docs, append(bson.Raw(nil), cursor.Current...))
+	}
+
+	if err := cursor.Err(); err != nil {
+		return page, err
+	}
+
+	hasMore := int64(len(docs)) > query.Limit
+	if hasMore {
+		docs = docs[:query.Limit]
+	}
+
+	//Restore requested order when the page was read backwards
+	if backward {
+		for i, j := 0, len(docs)-1; i < j; i, j = i+1, j-1 {
+			docs[i], docs[j] = docs[j], docs[i]
+		}
+	}
+
+	page.Items = make([]T, 0, len(docs))
+	for _, doc := range docs {
+		var item T
+		if err := bson.Unmarshal(doc, &item); err != nil {
+			return page, err
+		}
+		page.Items = append(page.Items, item)
+	}
+
+	if len(docs) == 0 {
+		return page, nil
+	}
+
+	var err error
+
+	//There is a next page if we read forward and found more documents or if we came back from a later page
+	if (!backward && hasMore) || backward {
+		page.NextCursor, err = cursorFromDocument(query, docs[len(docs)-1], false)
+		if err != nil {
+			return page, err
+		}
+	}
+
+	//There is a previous page if we moved forward from a cursor or read backwards and found more documents
+	if (!backward && query.Cursor != nil) || (backward && hasMore) {
+		page.PrevCursor, err = cursorFromDocument(query, docs[0], true)
+		if err != nil {
+			return page, err
+		}
+	}
+
+	return page, nil
+}
+
+// Function that builds a KeysetQuery from the raw query parameters applying default limit and decoding the cursor
+func NewKeysetQuery(field string, ascending bool, limit int64, cursorString string) (KeysetQuery, error) {
+	query := KeysetQuery{Field: field, Ascending: ascending, Limit: limit}
+
+	if query.Limit <= 0 {
+		query.Limit = DefaultPageLimit
+	}
+
+	if query.Limit > MaxPageLimit {
+		query.Limit = MaxPageLimit
+	}
+
+	if cursorString != "" {
+		cursor, err := DecodeCursor(cursorString)
+		if err != nil {
+			return query, err
+		}
+		query.Cursor = cursor
+	}
+
+	return query, nil
+}
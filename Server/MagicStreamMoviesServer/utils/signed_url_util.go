@@ -0,0 +1,99 @@
+//File containing code to sign and validate expiring URLs of protected files
+
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"os"
+	pathpkg "path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Read Secret Key used to sign URLs. It is a different key than the token ones so leaking a URL signature never helps to forge tokens
+var SECRET_URL_KEY string = os.Getenv("SECRET_URL_KEY")
+
+// Query parameters carried by a signed URL
+const SignedPathParam = "path"
+const SignedExpiresParam = "expires"
+const SignedUserParam = "uid"
+const SignedSignatureParam = "sig"
+
+var ErrMissingURLKey = errors.New("SECRET_URL_KEY not set")
+var ErrInvalidSignature = errors.New("invalid signature")
+var ErrSignatureExpired = errors.New("signed url has expired")
+
+// Claims of a validated signed URL
+type SignedURLDetails struct {
+	Path      string
+	UserId    string
+	ExpiresAt time.Time
+}
+
+// Function that returns the signature of path, expiry and user id
+func urlSignature(path string, expires int64, userId string) string {
+	mac := hmac.New(sha256.New, []byte(SECRET_URL_KEY))
+	mac.Write([]byte(path + "\n" + strconv.FormatInt(expires, 10) + "\n" + userId))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Function that returns the query string granting userId access to path until expiresAt. A path ending with / grants
+// access to every file below it (for example the playlists and segments of an HLS package)
+func GenerateSignedQuery(path, userId string, expiresAt time.Time) (string, error) {
+	if SECRET_URL_KEY == "" {
+		return "", ErrMissingURLKey
+	}
+
+	expires := expiresAt.Unix()
+
+	query := url.Values{}
+	query.Set(SignedPathParam, path)
+	query.Set(SignedExpiresParam, strconv.FormatInt(expires, 10))
+	query.Set(SignedUserParam, userId)
+	query.Set(SignedSignatureParam, urlSignature(path, expires, userId))
+
+	return query.Encode(), nil
+}
+
+// Function that validates the signature of a request to requestPath. Only the secret key is needed, no database lookups
+func ValidateSignedURL(requestPath string, query url.Values) (*SignedURLDetails, error) {
+	if SECRET_URL_KEY == "" {
+		return nil, ErrMissingURLKey
+	}
+
+	path := query.Get(SignedPathParam)
+	userId := query.Get(SignedUserParam)
+
+	expires, err := strconv.ParseInt(query.Get(SignedExpiresParam), 10, 64)
+	if err != nil || path == "" || userId == "" {
+		return nil, ErrInvalidSignature
+	}
+
+	//Compare in constant time so the signature can not be guessed byte by byte
+	expected := urlSignature(path, expires, userId)
+	if !hmac.Equal([]byte(expected), []byte(query.Get(SignedSignatureParam))) {
+		return nil, ErrInvalidSignature
+	}
+
+	//Dot segments could walk out of a signed directory
+	if requestPath != pathpkg.Clean(requestPath) {
+		return nil, ErrInvalidSignature
+	}
+
+	if requestPath != path && !(strings.HasSuffix(path, "/") && strings.HasPrefix(requestPath, path)) {
+		return nil, ErrInvalidSignature
+	}
+
+	expiresAt := time.Unix(expires, 0)
+	if expiresAt.Before(time.Now()) {
+		return nil, ErrSignatureExpired
+	}
+
+	return &SignedURLDetails{Path: path, UserId: userId, ExpiresAt: expiresAt}, nil
+}